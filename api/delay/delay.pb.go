@@ -13,6 +13,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -26,14 +27,37 @@ const (
 )
 
 type RegisterRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Schema        string                 `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
-	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
-	Path          string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
-	Data          *structpb.Struct       `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
-	DelayTime     int64                  `protobuf:"varint,7,opt,name=delay_time,json=delayTime,proto3" json:"delay_time,omitempty"`
-	Timeout       int64                  `protobuf:"varint,8,opt,name=timeout,proto3" json:"timeout,omitempty"`
-	Backoff       []int64                `protobuf:"varint,9,rep,packed,name=backoff,proto3" json:"backoff,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Schema string                 `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	// 回调地址，PUSH、EXEC 不使用
+	Url       string           `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Path      string           `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Data      *structpb.Struct `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	DelayTime int64            `protobuf:"varint,7,opt,name=delay_time,json=delayTime,proto3" json:"delay_time,omitempty"`
+	Timeout   int64            `protobuf:"varint,8,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Backoff   []int64          `protobuf:"varint,9,rep,packed,name=backoff,proto3" json:"backoff,omitempty"`
+	// 回调签名密钥，为空时使用租户密钥
+	Secret string `protobuf:"bytes,10,opt,name=secret,proto3" json:"secret,omitempty"`
+	// HTTP 回调：请求方法，默认 POST；REDIS 回调：XADD（默认）或 LPUSH
+	Method string `protobuf:"bytes,11,opt,name=method,proto3" json:"method,omitempty"`
+	// HTTP 回调：请求头
+	Headers map[string]string `protobuf:"bytes,12,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// HTTP 回调：查询参数
+	Query map[string]string `protobuf:"bytes,13,rep,name=query,proto3" json:"query,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// HTTP 回调：内容类型，默认 json
+	ContentType string `protobuf:"bytes,14,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// HTTP 回调：请求体模板（text/template），可用字段 .TaskNo .Tenant .TraceId .Data
+	Template string `protobuf:"bytes,15,opt,name=template,proto3" json:"template,omitempty"`
+	// 消息回调：KAFKA 消息键（默认任务编号），AMQP routing key
+	Key string `protobuf:"bytes,16,opt,name=key,proto3" json:"key,omitempty"`
+	// 回调成功后将响应转发到该回调，转发任务的 data.parent 为 {"task_no": 原任务编号, "result": 响应}
+	OnComplete *Callback `protobuf:"bytes,17,opt,name=on_complete,json=onComplete,proto3" json:"on_complete,omitempty"`
+	// 父任务编号，父任务全部成功后才开始计算 delay_time
+	Parents []int64 `protobuf:"varint,18,rep,packed,name=parents,proto3" json:"parents,omitempty"`
+	// 父任务失败或取消时的处理方式：cancel（默认）取消，fail 标记失败，run 继续调度
+	OnParentFailure string `protobuf:"bytes,19,opt,name=on_parent_failure,json=onParentFailure,proto3" json:"on_parent_failure,omitempty"`
+	// 回调数据格式：raw（默认）请求体为 data，任务信息在请求头中；envelope 请求体为含任务信息的信封
+	Mode          string `protobuf:"bytes,20,opt,name=mode,proto3" json:"mode,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -117,6 +141,216 @@ func (x *RegisterRequest) GetBackoff() []int64 {
 	return nil
 }
 
+func (x *RegisterRequest) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *RegisterRequest) GetQuery() map[string]string {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *RegisterRequest) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetOnComplete() *Callback {
+	if x != nil {
+		return x.OnComplete
+	}
+	return nil
+}
+
+func (x *RegisterRequest) GetParents() []int64 {
+	if x != nil {
+		return x.Parents
+	}
+	return nil
+}
+
+func (x *RegisterRequest) GetOnParentFailure() string {
+	if x != nil {
+		return x.OnParentFailure
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+// Callback 回调参数，含义同 RegisterRequest
+type Callback struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schema        string                 `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Path          string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Data          *structpb.Struct       `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	Secret        string                 `protobuf:"bytes,5,opt,name=secret,proto3" json:"secret,omitempty"`
+	Method        string                 `protobuf:"bytes,6,opt,name=method,proto3" json:"method,omitempty"`
+	Headers       map[string]string      `protobuf:"bytes,7,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Query         map[string]string      `protobuf:"bytes,8,rep,name=query,proto3" json:"query,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ContentType   string                 `protobuf:"bytes,9,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Template      string                 `protobuf:"bytes,10,opt,name=template,proto3" json:"template,omitempty"`
+	Key           string                 `protobuf:"bytes,11,opt,name=key,proto3" json:"key,omitempty"`
+	Mode          string                 `protobuf:"bytes,12,opt,name=mode,proto3" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Callback) Reset() {
+	*x = Callback{}
+	mi := &file_delay_delay_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Callback) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Callback) ProtoMessage() {}
+
+func (x *Callback) ProtoReflect() protoreflect.Message {
+	mi := &file_delay_delay_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Callback.ProtoReflect.Descriptor instead.
+func (*Callback) Descriptor() ([]byte, []int) {
+	return file_delay_delay_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Callback) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *Callback) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Callback) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Callback) GetData() *structpb.Struct {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Callback) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+func (x *Callback) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *Callback) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *Callback) GetQuery() map[string]string {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *Callback) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Callback) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *Callback) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Callback) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
 type RegisterReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	TaskNo        int64                  `protobuf:"varint,1,opt,name=task_no,json=taskNo,proto3" json:"task_no,omitempty"`
@@ -126,7 +360,7 @@ type RegisterReply struct {
 
 func (x *RegisterReply) Reset() {
 	*x = RegisterReply{}
-	mi := &file_delay_delay_proto_msgTypes[1]
+	mi := &file_delay_delay_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -138,7 +372,7 @@ func (x *RegisterReply) String() string {
 func (*RegisterReply) ProtoMessage() {}
 
 func (x *RegisterReply) ProtoReflect() protoreflect.Message {
-	mi := &file_delay_delay_proto_msgTypes[1]
+	mi := &file_delay_delay_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -151,7 +385,7 @@ func (x *RegisterReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RegisterReply.ProtoReflect.Descriptor instead.
 func (*RegisterReply) Descriptor() ([]byte, []int) {
-	return file_delay_delay_proto_rawDescGZIP(), []int{1}
+	return file_delay_delay_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *RegisterReply) GetTaskNo() int64 {
@@ -161,24 +395,357 @@ func (x *RegisterReply) GetTaskNo() int64 {
 	return 0
 }
 
+type QueryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskNo        int64                  `protobuf:"varint,1,opt,name=task_no,json=taskNo,proto3" json:"task_no,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryRequest) Reset() {
+	*x = QueryRequest{}
+	mi := &file_delay_delay_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRequest) ProtoMessage() {}
+
+func (x *QueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_delay_delay_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRequest.ProtoReflect.Descriptor instead.
+func (*QueryRequest) Descriptor() ([]byte, []int) {
+	return file_delay_delay_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *QueryRequest) GetTaskNo() int64 {
+	if x != nil {
+		return x.TaskNo
+	}
+	return 0
+}
+
+type QueryReply struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	TaskNo int64                  `protobuf:"varint,1,opt,name=task_no,json=taskNo,proto3" json:"task_no,omitempty"`
+	Tenant string                 `protobuf:"bytes,2,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Schema string                 `protobuf:"bytes,3,opt,name=schema,proto3" json:"schema,omitempty"`
+	Url    string                 `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`
+	Path   string                 `protobuf:"bytes,5,opt,name=path,proto3" json:"path,omitempty"`
+	Data   *structpb.Struct       `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	// 0待执行 1执行中 2成功 3失败 4已取消 5等待父任务
+	Status    int32                  `protobuf:"varint,7,opt,name=status,proto3" json:"status,omitempty"`
+	FailCount int32                  `protobuf:"varint,8,opt,name=fail_count,json=failCount,proto3" json:"fail_count,omitempty"`
+	NextRunAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=next_run_at,json=nextRunAt,proto3" json:"next_run_at,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// 成功回调的响应，超出 result_limit 时截断
+	Result        string `protobuf:"bytes,12,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryReply) Reset() {
+	*x = QueryReply{}
+	mi := &file_delay_delay_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryReply) ProtoMessage() {}
+
+func (x *QueryReply) ProtoReflect() protoreflect.Message {
+	mi := &file_delay_delay_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryReply.ProtoReflect.Descriptor instead.
+func (*QueryReply) Descriptor() ([]byte, []int) {
+	return file_delay_delay_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *QueryReply) GetTaskNo() int64 {
+	if x != nil {
+		return x.TaskNo
+	}
+	return 0
+}
+
+func (x *QueryReply) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *QueryReply) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *QueryReply) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *QueryReply) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *QueryReply) GetData() *structpb.Struct {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *QueryReply) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *QueryReply) GetFailCount() int32 {
+	if x != nil {
+		return x.FailCount
+	}
+	return 0
+}
+
+func (x *QueryReply) GetNextRunAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextRunAt
+	}
+	return nil
+}
+
+func (x *QueryReply) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *QueryReply) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *QueryReply) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+type CancelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskNo        int64                  `protobuf:"varint,1,opt,name=task_no,json=taskNo,proto3" json:"task_no,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelRequest) Reset() {
+	*x = CancelRequest{}
+	mi := &file_delay_delay_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelRequest) ProtoMessage() {}
+
+func (x *CancelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_delay_delay_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelRequest.ProtoReflect.Descriptor instead.
+func (*CancelRequest) Descriptor() ([]byte, []int) {
+	return file_delay_delay_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CancelRequest) GetTaskNo() int64 {
+	if x != nil {
+		return x.TaskNo
+	}
+	return 0
+}
+
+type CancelReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Canceled      bool                   `protobuf:"varint,1,opt,name=canceled,proto3" json:"canceled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelReply) Reset() {
+	*x = CancelReply{}
+	mi := &file_delay_delay_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelReply) ProtoMessage() {}
+
+func (x *CancelReply) ProtoReflect() protoreflect.Message {
+	mi := &file_delay_delay_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelReply.ProtoReflect.Descriptor instead.
+func (*CancelReply) Descriptor() ([]byte, []int) {
+	return file_delay_delay_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CancelReply) GetCanceled() bool {
+	if x != nil {
+		return x.Canceled
+	}
+	return false
+}
+
 var File_delay_delay_proto protoreflect.FileDescriptor
 
 const file_delay_delay_proto_rawDesc = "" +
 	"\n" +
-	"\x11delay/delay.proto\x12\x05delay\x1a\x1cgoogle/api/annotations.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x17validate/validate.proto\x1a\x1bvalidate/validate_ext.proto\"\xd8\x04\n" +
-	"\x0fRegisterRequest\x12l\n" +
-	"\x06schema\x18\x01 \x01(\tBT\xfaB\x1ar\x18R\x03FMTR\x04HTTPR\x05HTTPSR\x04GRPC\x8a\xb5\x183schema 必须是 FMT、HTTP、HTTPS 或 GRPC 之一R\x06schema\x12S\n" +
-	"\x03url\x18\x02 \x01(\tBA\xfaB\ar\x05\x10\x01\x18\xff\x01\x8a\xb5\x183url 不能为空且长度不能超过 255 个字符R\x03url\x12V\n" +
+	"\x11delay/delay.proto\x12\x05delay\x1a\x1cgoogle/api/annotations.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x17validate/validate.proto\x1a\x1bvalidate/validate_ext.proto\"\xef\f\n" +
+	"\x0fRegisterRequest\x12P\n" +
+	"\x06schema\x18\x01 \x01(\tB8\xfaB\x06r\x04\x10\x01\x18@\x8a\xb5\x18+schema 必须是已配置的回调实例名R\x06schema\x12B\n" +
+	"\x03url\x18\x02 \x01(\tB0\xfaB\x05r\x03\x18\xff\x01\x8a\xb5\x18$url 长度不能超过 255 个字符R\x03url\x12V\n" +
 	"\x04path\x18\x03 \x01(\tBB\xfaB\ar\x05\x10\x01\x18\xff\x01\x8a\xb5\x184path 不能为空且长度不能超过 255 个字符R\x04path\x12+\n" +
 	"\x04data\x18\x04 \x01(\v2\x17.google.protobuf.StructR\x04data\x12X\n" +
 	"\n" +
 	"delay_time\x18\a \x01(\x03B9\xfaB\b\"\x06\x18\x80\xa3\x05(\x00\x8a\xb5\x18*delay_time 必须在 0 到 86400 秒之间R\tdelayTime\x12N\n" +
 	"\atimeout\x18\b \x01(\x03B4\xfaB\a\"\x05\x18\x90\x1c(\x00\x8a\xb5\x18&timeout 必须在 0 到 3600 秒之间R\atimeout\x12S\n" +
-	"\abackoff\x18\t \x03(\x03B9\xfaB\x05\x92\x01\x02\x10\x14\x8a\xb5\x18-backoff 数组长度不能超过 20 个元素R\abackoff\"(\n" +
+	"\abackoff\x18\t \x03(\x03B9\xfaB\x05\x92\x01\x02\x10\x14\x8a\xb5\x18-backoff 数组长度不能超过 20 个元素R\abackoff\x12K\n" +
+	"\x06secret\x18\n" +
+	" \x01(\tB3\xfaB\x05r\x03\x18\x80\x01\x8a\xb5\x18'secret 长度不能超过 128 个字符R\x06secret\x12\x9a\x01\n" +
+	"\x06method\x18\v \x01(\tB\x81\x01\xfaB0r.R\x00R\x03GETR\x04POSTR\x03PUTR\x05PATCHR\x06DELETER\x04XADDR\x05LPUSH\x8a\xb5\x18Jmethod 必须是 GET、POST、PUT、PATCH、DELETE、XADD 或 LPUSH 之一R\x06method\x12=\n" +
+	"\aheaders\x18\f \x03(\v2#.delay.RegisterRequest.HeadersEntryR\aheaders\x127\n" +
+	"\x05query\x18\r \x03(\v2!.delay.RegisterRequest.QueryEntryR\x05query\x12p\n" +
+	"\fcontent_type\x18\x0e \x01(\tBM\xfaB\x15r\x13R\x00R\x04jsonR\x04formR\x03raw\x8a\xb5\x181content_type 必须是 json、form 或 raw 之一R\vcontentType\x12R\n" +
+	"\btemplate\x18\x0f \x01(\tB6\xfaB\x05r\x03\x18\x80@\x8a\xb5\x18*template 长度不能超过 8192 个字符R\btemplate\x12B\n" +
+	"\x03key\x18\x10 \x01(\tB0\xfaB\x05r\x03\x18\xff\x01\x8a\xb5\x18$key 长度不能超过 255 个字符R\x03key\x120\n" +
+	"\von_complete\x18\x11 \x01(\v2\x0f.delay.CallbackR\n" +
+	"onComplete\x12S\n" +
+	"\aparents\x18\x12 \x03(\x03B9\xfaB\x05\x92\x01\x02\x10\x14\x8a\xb5\x18-parents 数组长度不能超过 20 个元素R\aparents\x12\x82\x01\n" +
+	"\x11on_parent_failure\x18\x13 \x01(\tBV\xfaB\x17r\x15R\x00R\x06cancelR\x04failR\x03run\x8a\xb5\x188on_parent_failure 必须是 cancel、fail 或 run 之一R\x0fonParentFailure\x12T\n" +
+	"\x04mode\x18\x14 \x01(\tB@\xfaB\x13r\x11R\x00R\x03rawR\benvelope\x8a\xb5\x18&mode 必须是 raw 或 envelope 之一R\x04mode\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a8\n" +
+	"\n" +
+	"QueryEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xcf\b\n" +
+	"\bCallback\x12P\n" +
+	"\x06schema\x18\x01 \x01(\tB8\xfaB\x06r\x04\x10\x01\x18@\x8a\xb5\x18+schema 必须是已配置的回调实例名R\x06schema\x12B\n" +
+	"\x03url\x18\x02 \x01(\tB0\xfaB\x05r\x03\x18\xff\x01\x8a\xb5\x18$url 长度不能超过 255 个字符R\x03url\x12V\n" +
+	"\x04path\x18\x03 \x01(\tBB\xfaB\ar\x05\x10\x01\x18\xff\x01\x8a\xb5\x184path 不能为空且长度不能超过 255 个字符R\x04path\x12+\n" +
+	"\x04data\x18\x04 \x01(\v2\x17.google.protobuf.StructR\x04data\x12K\n" +
+	"\x06secret\x18\x05 \x01(\tB3\xfaB\x05r\x03\x18\x80\x01\x8a\xb5\x18'secret 长度不能超过 128 个字符R\x06secret\x12\x9a\x01\n" +
+	"\x06method\x18\x06 \x01(\tB\x81\x01\xfaB0r.R\x00R\x03GETR\x04POSTR\x03PUTR\x05PATCHR\x06DELETER\x04XADDR\x05LPUSH\x8a\xb5\x18Jmethod 必须是 GET、POST、PUT、PATCH、DELETE、XADD 或 LPUSH 之一R\x06method\x126\n" +
+	"\aheaders\x18\a \x03(\v2\x1c.delay.Callback.HeadersEntryR\aheaders\x120\n" +
+	"\x05query\x18\b \x03(\v2\x1a.delay.Callback.QueryEntryR\x05query\x12p\n" +
+	"\fcontent_type\x18\t \x01(\tBM\xfaB\x15r\x13R\x00R\x04jsonR\x04formR\x03raw\x8a\xb5\x181content_type 必须是 json、form 或 raw 之一R\vcontentType\x12R\n" +
+	"\btemplate\x18\n" +
+	" \x01(\tB6\xfaB\x05r\x03\x18\x80@\x8a\xb5\x18*template 长度不能超过 8192 个字符R\btemplate\x12B\n" +
+	"\x03key\x18\v \x01(\tB0\xfaB\x05r\x03\x18\xff\x01\x8a\xb5\x18$key 长度不能超过 255 个字符R\x03key\x12T\n" +
+	"\x04mode\x18\f \x01(\tB@\xfaB\x13r\x11R\x00R\x03rawR\benvelope\x8a\xb5\x18&mode 必须是 raw 或 envelope 之一R\x04mode\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a8\n" +
+	"\n" +
+	"QueryEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"(\n" +
 	"\rRegisterReply\x12\x17\n" +
-	"\atask_no\x18\x01 \x01(\x03R\x06taskNo2]\n" +
+	"\atask_no\x18\x01 \x01(\x03R\x06taskNo\"H\n" +
+	"\fQueryRequest\x128\n" +
+	"\atask_no\x18\x01 \x01(\x03B\x1f\xfaB\x04\"\x02 \x00\x8a\xb5\x18\x14task_no 不能为空R\x06taskNo\"\xa9\x03\n" +
+	"\n" +
+	"QueryReply\x12\x17\n" +
+	"\atask_no\x18\x01 \x01(\x03R\x06taskNo\x12\x16\n" +
+	"\x06tenant\x18\x02 \x01(\tR\x06tenant\x12\x16\n" +
+	"\x06schema\x18\x03 \x01(\tR\x06schema\x12\x10\n" +
+	"\x03url\x18\x04 \x01(\tR\x03url\x12\x12\n" +
+	"\x04path\x18\x05 \x01(\tR\x04path\x12+\n" +
+	"\x04data\x18\x06 \x01(\v2\x17.google.protobuf.StructR\x04data\x12\x16\n" +
+	"\x06status\x18\a \x01(\x05R\x06status\x12\x1d\n" +
+	"\n" +
+	"fail_count\x18\b \x01(\x05R\tfailCount\x12:\n" +
+	"\vnext_run_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tnextRunAt\x129\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x16\n" +
+	"\x06result\x18\f \x01(\tR\x06result\"I\n" +
+	"\rCancelRequest\x128\n" +
+	"\atask_no\x18\x01 \x01(\x03B\x1f\xfaB\x04\"\x02 \x00\x8a\xb5\x18\x14task_no 不能为空R\x06taskNo\")\n" +
+	"\vCancelReply\x12\x1a\n" +
+	"\bcanceled\x18\x01 \x01(\bR\bcanceled2\xfc\x01\n" +
 	"\x05Delay\x12T\n" +
-	"\bRegister\x12\x16.delay.RegisterRequest\x1a\x14.delay.RegisterReply\"\x1a\x82\xd3\xe4\x93\x02\x14:\x01*\"\x0f/delay/registerB\x1aZ\x18github.com/x-thooh/delayb\x06proto3"
+	"\bRegister\x12\x16.delay.RegisterRequest\x1a\x14.delay.RegisterReply\"\x1a\x82\xd3\xe4\x93\x02\x14:\x01*\"\x0f/delay/register\x12O\n" +
+	"\x05Query\x12\x13.delay.QueryRequest\x1a\x11.delay.QueryReply\"\x1e\x82\xd3\xe4\x93\x02\x18\x12\x16/delay/query/{task_no}\x12L\n" +
+	"\x06Cancel\x12\x14.delay.CancelRequest\x1a\x12.delay.CancelReply\"\x18\x82\xd3\xe4\x93\x02\x12:\x01*\"\r/delay/cancelB\x1aZ\x18github.com/x-thooh/delayb\x06proto3"
 
 var (
 	file_delay_delay_proto_rawDescOnce sync.Once
@@ -192,21 +759,45 @@ func file_delay_delay_proto_rawDescGZIP() []byte {
 	return file_delay_delay_proto_rawDescData
 }
 
-var file_delay_delay_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_delay_delay_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_delay_delay_proto_goTypes = []any{
-	(*RegisterRequest)(nil), // 0: delay.RegisterRequest
-	(*RegisterReply)(nil),   // 1: delay.RegisterReply
-	(*structpb.Struct)(nil), // 2: google.protobuf.Struct
+	(*RegisterRequest)(nil),       // 0: delay.RegisterRequest
+	(*Callback)(nil),              // 1: delay.Callback
+	(*RegisterReply)(nil),         // 2: delay.RegisterReply
+	(*QueryRequest)(nil),          // 3: delay.QueryRequest
+	(*QueryReply)(nil),            // 4: delay.QueryReply
+	(*CancelRequest)(nil),         // 5: delay.CancelRequest
+	(*CancelReply)(nil),           // 6: delay.CancelReply
+	nil,                           // 7: delay.RegisterRequest.HeadersEntry
+	nil,                           // 8: delay.RegisterRequest.QueryEntry
+	nil,                           // 9: delay.Callback.HeadersEntry
+	nil,                           // 10: delay.Callback.QueryEntry
+	(*structpb.Struct)(nil),       // 11: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
 }
 var file_delay_delay_proto_depIdxs = []int32{
-	2, // 0: delay.RegisterRequest.data:type_name -> google.protobuf.Struct
-	0, // 1: delay.Delay.Register:input_type -> delay.RegisterRequest
-	1, // 2: delay.Delay.Register:output_type -> delay.RegisterReply
-	2, // [2:3] is the sub-list for method output_type
-	1, // [1:2] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	11, // 0: delay.RegisterRequest.data:type_name -> google.protobuf.Struct
+	7,  // 1: delay.RegisterRequest.headers:type_name -> delay.RegisterRequest.HeadersEntry
+	8,  // 2: delay.RegisterRequest.query:type_name -> delay.RegisterRequest.QueryEntry
+	1,  // 3: delay.RegisterRequest.on_complete:type_name -> delay.Callback
+	11, // 4: delay.Callback.data:type_name -> google.protobuf.Struct
+	9,  // 5: delay.Callback.headers:type_name -> delay.Callback.HeadersEntry
+	10, // 6: delay.Callback.query:type_name -> delay.Callback.QueryEntry
+	11, // 7: delay.QueryReply.data:type_name -> google.protobuf.Struct
+	12, // 8: delay.QueryReply.next_run_at:type_name -> google.protobuf.Timestamp
+	12, // 9: delay.QueryReply.created_at:type_name -> google.protobuf.Timestamp
+	12, // 10: delay.QueryReply.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 11: delay.Delay.Register:input_type -> delay.RegisterRequest
+	3,  // 12: delay.Delay.Query:input_type -> delay.QueryRequest
+	5,  // 13: delay.Delay.Cancel:input_type -> delay.CancelRequest
+	2,  // 14: delay.Delay.Register:output_type -> delay.RegisterReply
+	4,  // 15: delay.Delay.Query:output_type -> delay.QueryReply
+	6,  // 16: delay.Delay.Cancel:output_type -> delay.CancelReply
+	14, // [14:17] is the sub-list for method output_type
+	11, // [11:14] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_delay_delay_proto_init() }
@@ -220,7 +811,7 @@ func file_delay_delay_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_delay_delay_proto_rawDesc), len(file_delay_delay_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -57,10 +57,10 @@ func (m *RegisterRequest) validate(all bool) error {
 
 	var errors []error
 
-	if _, ok := _RegisterRequest_Schema_InLookup[m.GetSchema()]; !ok {
+	if l := utf8.RuneCountInString(m.GetSchema()); l < 1 || l > 64 {
 		err := RegisterRequestValidationError{
 			field:  "Schema",
-			reason: "value must be in list [FMT HTTP HTTPS GRPC]",
+			reason: "value length must be between 1 and 64 runes, inclusive",
 		}
 		if !all {
 			return err
@@ -68,10 +68,10 @@ func (m *RegisterRequest) validate(all bool) error {
 		errors = append(errors, err)
 	}
 
-	if l := utf8.RuneCountInString(m.GetUrl()); l < 1 || l > 255 {
+	if utf8.RuneCountInString(m.GetUrl()) > 255 {
 		err := RegisterRequestValidationError{
 			field:  "Url",
-			reason: "value length must be between 1 and 255 runes, inclusive",
+			reason: "value length must be at most 255 runes",
 		}
 		if !all {
 			return err
@@ -152,6 +152,127 @@ func (m *RegisterRequest) validate(all bool) error {
 		errors = append(errors, err)
 	}
 
+	if utf8.RuneCountInString(m.GetSecret()) > 128 {
+		err := RegisterRequestValidationError{
+			field:  "Secret",
+			reason: "value length must be at most 128 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _RegisterRequest_Method_InLookup[m.GetMethod()]; !ok {
+		err := RegisterRequestValidationError{
+			field:  "Method",
+			reason: "value must be in list [ GET POST PUT PATCH DELETE XADD LPUSH]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	// no validation rules for Headers
+
+	// no validation rules for Query
+
+	if _, ok := _RegisterRequest_ContentType_InLookup[m.GetContentType()]; !ok {
+		err := RegisterRequestValidationError{
+			field:  "ContentType",
+			reason: "value must be in list [ json form raw]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetTemplate()) > 8192 {
+		err := RegisterRequestValidationError{
+			field:  "Template",
+			reason: "value length must be at most 8192 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetKey()) > 255 {
+		err := RegisterRequestValidationError{
+			field:  "Key",
+			reason: "value length must be at most 255 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if all {
+		switch v := interface{}(m.GetOnComplete()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, RegisterRequestValidationError{
+					field:  "OnComplete",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, RegisterRequestValidationError{
+					field:  "OnComplete",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetOnComplete()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return RegisterRequestValidationError{
+				field:  "OnComplete",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(m.GetParents()) > 20 {
+		err := RegisterRequestValidationError{
+			field:  "Parents",
+			reason: "value must contain no more than 20 item(s)",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _RegisterRequest_OnParentFailure_InLookup[m.GetOnParentFailure()]; !ok {
+		err := RegisterRequestValidationError{
+			field:  "OnParentFailure",
+			reason: "value must be in list [ cancel fail run]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _RegisterRequest_Mode_InLookup[m.GetMode()]; !ok {
+		err := RegisterRequestValidationError{
+			field:  "Mode",
+			reason: "value must be in list [ raw envelope]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
 	if len(errors) > 0 {
 		return RegisterRequestMultiError(errors)
 	}
@@ -230,51 +351,204 @@ var _ interface {
 	ErrorName() string
 } = RegisterRequestValidationError{}
 
-var _RegisterRequest_Schema_InLookup = map[string]struct{}{
-	"FMT":   {},
-	"HTTP":  {},
-	"HTTPS": {},
-	"GRPC":  {},
+var _RegisterRequest_Method_InLookup = map[string]struct{}{
+	"":       {},
+	"GET":    {},
+	"POST":   {},
+	"PUT":    {},
+	"PATCH":  {},
+	"DELETE": {},
+	"XADD":   {},
+	"LPUSH":  {},
 }
 
-// Validate checks the field values on RegisterReply with the rules defined in
-// the proto definition for this message. If any rules are violated, the first
+var _RegisterRequest_ContentType_InLookup = map[string]struct{}{
+	"":     {},
+	"json": {},
+	"form": {},
+	"raw":  {},
+}
+
+var _RegisterRequest_OnParentFailure_InLookup = map[string]struct{}{
+	"":       {},
+	"cancel": {},
+	"fail":   {},
+	"run":    {},
+}
+
+var _RegisterRequest_Mode_InLookup = map[string]struct{}{
+	"":         {},
+	"raw":      {},
+	"envelope": {},
+}
+
+// Validate checks the field values on Callback with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
 // error encountered is returned, or nil if there are no violations.
-func (m *RegisterReply) Validate() error {
+func (m *Callback) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on RegisterReply with the rules defined
-// in the proto definition for this message. If any rules are violated, the
-// result is a list of violation errors wrapped in RegisterReplyMultiError, or
-// nil if none found.
-func (m *RegisterReply) ValidateAll() error {
+// ValidateAll checks the field values on Callback with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in CallbackMultiError, or nil
+// if none found.
+func (m *Callback) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *RegisterReply) validate(all bool) error {
+func (m *Callback) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
-	// no validation rules for TaskNo
+	if l := utf8.RuneCountInString(m.GetSchema()); l < 1 || l > 64 {
+		err := CallbackValidationError{
+			field:  "Schema",
+			reason: "value length must be between 1 and 64 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetUrl()) > 255 {
+		err := CallbackValidationError{
+			field:  "Url",
+			reason: "value length must be at most 255 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if l := utf8.RuneCountInString(m.GetPath()); l < 1 || l > 255 {
+		err := CallbackValidationError{
+			field:  "Path",
+			reason: "value length must be between 1 and 255 runes, inclusive",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if all {
+		switch v := interface{}(m.GetData()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, CallbackValidationError{
+					field:  "Data",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, CallbackValidationError{
+					field:  "Data",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetData()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return CallbackValidationError{
+				field:  "Data",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if utf8.RuneCountInString(m.GetSecret()) > 128 {
+		err := CallbackValidationError{
+			field:  "Secret",
+			reason: "value length must be at most 128 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _Callback_Method_InLookup[m.GetMethod()]; !ok {
+		err := CallbackValidationError{
+			field:  "Method",
+			reason: "value must be in list [ GET POST PUT PATCH DELETE XADD LPUSH]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	// no validation rules for Headers
+
+	// no validation rules for Query
+
+	if _, ok := _Callback_ContentType_InLookup[m.GetContentType()]; !ok {
+		err := CallbackValidationError{
+			field:  "ContentType",
+			reason: "value must be in list [ json form raw]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetTemplate()) > 8192 {
+		err := CallbackValidationError{
+			field:  "Template",
+			reason: "value length must be at most 8192 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if utf8.RuneCountInString(m.GetKey()) > 255 {
+		err := CallbackValidationError{
+			field:  "Key",
+			reason: "value length must be at most 255 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _Callback_Mode_InLookup[m.GetMode()]; !ok {
+		err := CallbackValidationError{
+			field:  "Mode",
+			reason: "value must be in list [ raw envelope]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
 
 	if len(errors) > 0 {
-		return RegisterReplyMultiError(errors)
+		return CallbackMultiError(errors)
 	}
 
 	return nil
 }
 
-// RegisterReplyMultiError is an error wrapping multiple validation errors
-// returned by RegisterReply.ValidateAll() if the designated constraints
-// aren't met.
-type RegisterReplyMultiError []error
+// CallbackMultiError is an error wrapping multiple validation errors returned
+// by Callback.ValidateAll() if the designated constraints aren't met.
+type CallbackMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m RegisterReplyMultiError) Error() string {
+func (m CallbackMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -283,11 +557,11 @@ func (m RegisterReplyMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m RegisterReplyMultiError) AllErrors() []error { return m }
+func (m CallbackMultiError) AllErrors() []error { return m }
 
-// RegisterReplyValidationError is the validation error returned by
-// RegisterReply.Validate if the designated constraints aren't met.
-type RegisterReplyValidationError struct {
+// CallbackValidationError is the validation error returned by
+// Callback.Validate if the designated constraints aren't met.
+type CallbackValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -295,22 +569,22 @@ type RegisterReplyValidationError struct {
 }
 
 // Field function returns field value.
-func (e RegisterReplyValidationError) Field() string { return e.field }
+func (e CallbackValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e RegisterReplyValidationError) Reason() string { return e.reason }
+func (e CallbackValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e RegisterReplyValidationError) Cause() error { return e.cause }
+func (e CallbackValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e RegisterReplyValidationError) Key() bool { return e.key }
+func (e CallbackValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e RegisterReplyValidationError) ErrorName() string { return "RegisterReplyValidationError" }
+func (e CallbackValidationError) ErrorName() string { return "CallbackValidationError" }
 
 // Error satisfies the builtin error interface
-func (e RegisterReplyValidationError) Error() string {
+func (e CallbackValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -322,14 +596,14 @@ func (e RegisterReplyValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sRegisterReply.%s: %s%s",
+		"invalid %sCallback.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = RegisterReplyValidationError{}
+var _ error = CallbackValidationError{}
 
 var _ interface {
 	Field() string
@@ -337,4 +611,686 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = RegisterReplyValidationError{}
+} = CallbackValidationError{}
+
+var _Callback_Method_InLookup = map[string]struct{}{
+	"":       {},
+	"GET":    {},
+	"POST":   {},
+	"PUT":    {},
+	"PATCH":  {},
+	"DELETE": {},
+	"XADD":   {},
+	"LPUSH":  {},
+}
+
+var _Callback_ContentType_InLookup = map[string]struct{}{
+	"":     {},
+	"json": {},
+	"form": {},
+	"raw":  {},
+}
+
+var _Callback_Mode_InLookup = map[string]struct{}{
+	"":         {},
+	"raw":      {},
+	"envelope": {},
+}
+
+// Validate checks the field values on RegisterReply with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *RegisterReply) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on RegisterReply with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in RegisterReplyMultiError, or
+// nil if none found.
+func (m *RegisterReply) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *RegisterReply) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for TaskNo
+
+	if len(errors) > 0 {
+		return RegisterReplyMultiError(errors)
+	}
+
+	return nil
+}
+
+// RegisterReplyMultiError is an error wrapping multiple validation errors
+// returned by RegisterReply.ValidateAll() if the designated constraints
+// aren't met.
+type RegisterReplyMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m RegisterReplyMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m RegisterReplyMultiError) AllErrors() []error { return m }
+
+// RegisterReplyValidationError is the validation error returned by
+// RegisterReply.Validate if the designated constraints aren't met.
+type RegisterReplyValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e RegisterReplyValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e RegisterReplyValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e RegisterReplyValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e RegisterReplyValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e RegisterReplyValidationError) ErrorName() string { return "RegisterReplyValidationError" }
+
+// Error satisfies the builtin error interface
+func (e RegisterReplyValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sRegisterReply.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = RegisterReplyValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = RegisterReplyValidationError{}
+
+// Validate checks the field values on QueryRequest with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *QueryRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on QueryRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in QueryRequestMultiError, or
+// nil if none found.
+func (m *QueryRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *QueryRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetTaskNo() <= 0 {
+		err := QueryRequestValidationError{
+			field:  "TaskNo",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return QueryRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// QueryRequestMultiError is an error wrapping multiple validation errors
+// returned by QueryRequest.ValidateAll() if the designated constraints aren't
+// met.
+type QueryRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m QueryRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m QueryRequestMultiError) AllErrors() []error { return m }
+
+// QueryRequestValidationError is the validation error returned by
+// QueryRequest.Validate if the designated constraints aren't met.
+type QueryRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e QueryRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e QueryRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e QueryRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e QueryRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e QueryRequestValidationError) ErrorName() string { return "QueryRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e QueryRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sQueryRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = QueryRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = QueryRequestValidationError{}
+
+// Validate checks the field values on QueryReply with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *QueryReply) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on QueryReply with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in QueryReplyMultiError, or
+// nil if none found.
+func (m *QueryReply) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *QueryReply) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for TaskNo
+
+	// no validation rules for Tenant
+
+	// no validation rules for Schema
+
+	// no validation rules for Url
+
+	// no validation rules for Path
+
+	if all {
+		switch v := interface{}(m.GetData()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, QueryReplyValidationError{
+					field:  "Data",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, QueryReplyValidationError{
+					field:  "Data",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetData()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return QueryReplyValidationError{
+				field:  "Data",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Status
+
+	// no validation rules for FailCount
+
+	if all {
+		switch v := interface{}(m.GetNextRunAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, QueryReplyValidationError{
+					field:  "NextRunAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, QueryReplyValidationError{
+					field:  "NextRunAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetNextRunAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return QueryReplyValidationError{
+				field:  "NextRunAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetCreatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, QueryReplyValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, QueryReplyValidationError{
+					field:  "CreatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetCreatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return QueryReplyValidationError{
+				field:  "CreatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetUpdatedAt()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, QueryReplyValidationError{
+					field:  "UpdatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, QueryReplyValidationError{
+					field:  "UpdatedAt",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetUpdatedAt()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return QueryReplyValidationError{
+				field:  "UpdatedAt",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Result
+
+	if len(errors) > 0 {
+		return QueryReplyMultiError(errors)
+	}
+
+	return nil
+}
+
+// QueryReplyMultiError is an error wrapping multiple validation errors
+// returned by QueryReply.ValidateAll() if the designated constraints aren't
+// met.
+type QueryReplyMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m QueryReplyMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m QueryReplyMultiError) AllErrors() []error { return m }
+
+// QueryReplyValidationError is the validation error returned by
+// QueryReply.Validate if the designated constraints aren't met.
+type QueryReplyValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e QueryReplyValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e QueryReplyValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e QueryReplyValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e QueryReplyValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e QueryReplyValidationError) ErrorName() string { return "QueryReplyValidationError" }
+
+// Error satisfies the builtin error interface
+func (e QueryReplyValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sQueryReply.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = QueryReplyValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = QueryReplyValidationError{}
+
+// Validate checks the field values on CancelRequest with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *CancelRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CancelRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in CancelRequestMultiError, or
+// nil if none found.
+func (m *CancelRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CancelRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if m.GetTaskNo() <= 0 {
+		err := CancelRequestValidationError{
+			field:  "TaskNo",
+			reason: "value must be greater than 0",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return CancelRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// CancelRequestMultiError is an error wrapping multiple validation errors
+// returned by CancelRequest.ValidateAll() if the designated constraints
+// aren't met.
+type CancelRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CancelRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CancelRequestMultiError) AllErrors() []error { return m }
+
+// CancelRequestValidationError is the validation error returned by
+// CancelRequest.Validate if the designated constraints aren't met.
+type CancelRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CancelRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CancelRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CancelRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CancelRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CancelRequestValidationError) ErrorName() string { return "CancelRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e CancelRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCancelRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CancelRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CancelRequestValidationError{}
+
+// Validate checks the field values on CancelReply with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *CancelReply) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CancelReply with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in CancelReplyMultiError, or
+// nil if none found.
+func (m *CancelReply) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CancelReply) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Canceled
+
+	if len(errors) > 0 {
+		return CancelReplyMultiError(errors)
+	}
+
+	return nil
+}
+
+// CancelReplyMultiError is an error wrapping multiple validation errors
+// returned by CancelReply.ValidateAll() if the designated constraints aren't
+// met.
+type CancelReplyMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CancelReplyMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CancelReplyMultiError) AllErrors() []error { return m }
+
+// CancelReplyValidationError is the validation error returned by
+// CancelReply.Validate if the designated constraints aren't met.
+type CancelReplyValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CancelReplyValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CancelReplyValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CancelReplyValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CancelReplyValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CancelReplyValidationError) ErrorName() string { return "CancelReplyValidationError" }
+
+// Error satisfies the builtin error interface
+func (e CancelReplyValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCancelReply.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CancelReplyValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CancelReplyValidationError{}
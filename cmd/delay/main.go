@@ -24,10 +24,14 @@ var (
 	name = "delay"
 	env  = "prod"
 	conf = "../../configs"
+
+	// 节点无法继续工作的原因，非空时以失败退出
+	fatal error
 )
 
 func newApp(lg log.Logger, h *sh.Server, g *sg.Server, s *storage.Storage) *app.App {
-	return app.New(
+	var ap *app.App
+	ap = app.New(
 		app.Context(trace.Set(context.Background(), trace.GenerateTraceID())),
 		app.Metadata(map[string]string{}),
 		app.Logger(&logger.DefaultLogger{Lg: lg}),
@@ -36,7 +40,19 @@ func newApp(lg log.Logger, h *sh.Server, g *sg.Server, s *storage.Storage) *app.
 			g,
 			s,
 		),
+		// 节点无法继续工作时停止应用
+		app.AfterStart(func(ctx context.Context) error {
+			go func() {
+				select {
+				case fatal = <-s.Fatal():
+					_ = ap.Stop()
+				case <-ctx.Done():
+				}
+			}()
+			return nil
+		}),
 	)
+	return ap
 }
 
 func main() {
@@ -60,5 +76,8 @@ func main() {
 	if err = ap.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		panic(err)
 	}
+	if fatal != nil {
+		panic(fatal)
+	}
 
 }
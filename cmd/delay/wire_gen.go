@@ -10,11 +10,13 @@ import (
 	"github.com/x-thooh/delay/internal/boot/database"
 	"github.com/x-thooh/delay/internal/boot/logger"
 	"github.com/x-thooh/delay/internal/config"
+	"github.com/x-thooh/delay/internal/server/auth"
 	"github.com/x-thooh/delay/internal/server/grpc"
 	"github.com/x-thooh/delay/internal/server/http"
 	"github.com/x-thooh/delay/internal/service"
 	"github.com/x-thooh/delay/internal/service/delay"
 	"github.com/x-thooh/delay/internal/service/example"
+	"github.com/x-thooh/delay/internal/service/push"
 	"github.com/x-thooh/delay/pkg/app"
 )
 
@@ -28,8 +30,12 @@ func wireApp(entity *config.Entity) (*app.App, func(), error) {
 		return nil, nil, err
 	}
 	httpConfig := config.RegisterHTTP(entity)
-	server := http.New(httpConfig)
-	grpcConfig := config.RegisterGRPC(entity)
+	authConfig := config.RegisterAuth(entity)
+	authenticator, err := auth.New(authConfig)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
 	storageConfig := config.RegisterTimingWheel(entity)
 	databaseConfig := config.RegisterDatabase(entity)
 	db, err := database.InitSQLX(logLogger, databaseConfig)
@@ -37,14 +43,17 @@ func wireApp(entity *config.Entity) (*app.App, func(), error) {
 		cleanup()
 		return nil, nil, err
 	}
-	storage, err := service.RegisterStorage(storageConfig, logLogger, db)
+	hub := push.NewHub()
+	storage, err := service.RegisterStorage(storageConfig, logLogger, db, hub)
 	if err != nil {
 		cleanup()
 		return nil, nil, err
 	}
+	server := http.New(httpConfig, authenticator, storage, hub)
+	grpcConfig := config.RegisterGRPC(entity)
 	delayServer := delay.New(storage)
 	exampleServer := example.New(logLogger)
-	grpcServer := grpc.New(grpcConfig, logLogger, delayServer, exampleServer)
+	grpcServer := grpc.New(grpcConfig, logLogger, authenticator, delayServer, exampleServer)
 	appApp := newApp(logLogger, server, grpcServer, storage)
 	return appApp, func() {
 		cleanup()
@@ -2,6 +2,7 @@ package config
 
 import (
 	"github.com/x-thooh/delay/internal/boot/database"
+	"github.com/x-thooh/delay/internal/server/auth"
 	"github.com/x-thooh/delay/internal/server/grpc"
 	"github.com/x-thooh/delay/internal/server/http"
 	"github.com/x-thooh/delay/internal/service/storage"
@@ -13,6 +14,7 @@ type Entity struct {
 	Logger      *log.Config      `yaml:"logger"`
 	HTTP        *http.Config     `yaml:"http"`
 	GRPC        *grpc.Config     `yaml:"grpc"`
+	Auth        *auth.Config     `yaml:"auth"`
 	Database    *database.Config `yaml:"database"`
 	TimingWheel *storage.Config  `yaml:"timingwheel"`
 }
@@ -33,6 +35,10 @@ func RegisterGRPC(entity *Entity) *grpc.Config {
 	return entity.GRPC
 }
 
+func RegisterAuth(entity *Entity) *auth.Config {
+	return entity.Auth
+}
+
 func RegisterDatabase(entity *Entity) *database.Config {
 	return entity.Database
 }
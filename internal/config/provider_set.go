@@ -8,6 +8,7 @@ var ProviderSetConfig = wire.NewSet(
 	RegisterLogger,
 	RegisterHTTP,
 	RegisterGRPC,
+	RegisterAuth,
 	RegisterDatabase,
 	RegisterTimingWheel,
 )
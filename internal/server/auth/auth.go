@@ -0,0 +1,344 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	MethodAPIKey = "api_key"
+	MethodHMAC   = "hmac"
+	MethodJWT    = "jwt"
+
+	HeaderAPIKey        = "X-Api-Key"
+	HeaderAuthorization = "Authorization"
+	HeaderKeyId         = "X-Key-Id"
+	HeaderTimestamp     = "X-Timestamp"
+	HeaderNonce         = "X-Nonce"
+	HeaderSignature     = "X-Signature"
+
+	// 网关转发的已认证身份
+	HeaderIdentity          = "X-Auth-Identity"
+	HeaderIdentitySignature = "X-Auth-Identity-Signature"
+)
+
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+type Config struct {
+	Enable bool `yaml:"enable"`
+	// 静态 API Key
+	APIKeys []*APIKey `yaml:"api_keys"`
+	// HMAC 签名密钥
+	HMACKeys []*HMACKey `yaml:"hmac_keys"`
+	// 签名时间允许的偏差，同一 nonce 在该时间内只能使用一次
+	MaxSkew time.Duration `yaml:"max_skew"`
+	JWT     *JWTConfig    `yaml:"jwt"`
+	// 免认证的方法（gRPC 全方法名或 HTTP 路径）
+	Skip []string `yaml:"skip"`
+	// 未绑定租户的身份使用该租户，为空时拒绝请求
+	DefaultTenant string `yaml:"default_tenant"`
+}
+
+type APIKey struct {
+	Name   string `yaml:"name"`
+	Key    string `yaml:"key"`
+	Tenant string `yaml:"tenant"`
+}
+
+type HMACKey struct {
+	KeyId  string `yaml:"key_id"`
+	Secret string `yaml:"secret"`
+	Tenant string `yaml:"tenant"`
+}
+
+type JWTConfig struct {
+	// 本地 JWKS 文件
+	JWKSFile string `yaml:"jwks_file"`
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// 租户所在的 claim，默认 tenant
+	TenantClaim string `yaml:"tenant_claim"`
+	// URL 查询参数中的 access_token 剩余有效期上限，默认 5m
+	QueryTTL time.Duration `yaml:"query_ttl"`
+}
+
+// Identity 认证后的调用方身份
+type Identity struct {
+	Subject string `json:"subject"`
+	Tenant  string `json:"tenant,omitempty"`
+	Method  string `json:"method"`
+}
+
+// Credential 请求携带的认证信息
+type Credential struct {
+	APIKey        string
+	Authorization string
+	KeyId         string
+	Timestamp     string
+	Nonce         string
+	Signature     string
+	// 参与签名的方法：gRPC 全方法名或 "POST /delay/register"
+	Method string
+	Body   []byte
+	// 凭据来自 URL 查询参数，仅接受短期 JWT
+	Query bool
+
+	Identity          string
+	IdentitySignature string
+}
+
+type Authenticator struct {
+	cfg     *Config
+	apiKeys map[string]*APIKey
+	hmac    map[string]*HMACKey
+	jwks    *JWKS
+	skip    map[string]struct{}
+	// 进程内网关转发身份的签名密钥
+	internal []byte
+	nonces   *nonceCache
+}
+
+func New(cfg *Config) (*Authenticator, error) {
+	if cfg == nil || !cfg.Enable {
+		return nil, nil
+	}
+	// 复制一份，默认值不写回调用方的配置
+	c := *cfg
+	if cfg.JWT != nil {
+		jc := *cfg.JWT
+		c.JWT = &jc
+	}
+	cfg = &c
+	a := &Authenticator{
+		cfg:      cfg,
+		apiKeys:  make(map[string]*APIKey, len(cfg.APIKeys)),
+		hmac:     make(map[string]*HMACKey, len(cfg.HMACKeys)),
+		skip:     make(map[string]struct{}, len(cfg.Skip)),
+		internal: make([]byte, 32),
+		nonces:   &nonceCache{seen: make(map[string]time.Time)},
+	}
+	if a.cfg.MaxSkew <= 0 {
+		a.cfg.MaxSkew = 5 * time.Minute
+	}
+	for _, k := range cfg.APIKeys {
+		a.apiKeys[k.Key] = k
+	}
+	for _, k := range cfg.HMACKeys {
+		a.hmac[k.KeyId] = k
+	}
+	for _, m := range cfg.Skip {
+		a.skip[m] = struct{}{}
+	}
+	if cfg.JWT != nil && cfg.JWT.JWKSFile != "" {
+		jwks, err := LoadJWKS(cfg.JWT.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.jwks = jwks
+		if cfg.JWT.TenantClaim == "" {
+			cfg.JWT.TenantClaim = "tenant"
+		}
+		if cfg.JWT.QueryTTL <= 0 {
+			cfg.JWT.QueryTTL = 5 * time.Minute
+		}
+	}
+	if _, err := rand.Read(a.internal); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Skip 判断方法是否免认证
+func (a *Authenticator) Skip(method string) bool {
+	if a == nil {
+		return true
+	}
+	_, ok := a.skip[method]
+	return ok
+}
+
+// Authenticate 认证调用方，未绑定租户的身份使用默认租户
+func (a *Authenticator) Authenticate(c *Credential) (*Identity, error) {
+	id, err := a.authenticate(c)
+	if err != nil {
+		return nil, err
+	}
+	if id.Tenant == "" {
+		id.Tenant = a.cfg.DefaultTenant
+	}
+	return id, nil
+}
+
+// authenticate 依次尝试网关转发身份、API Key、HMAC 签名、JWT
+func (a *Authenticator) authenticate(c *Credential) (*Identity, error) {
+	if c.Query {
+		// 查询参数会进入访问日志与浏览器历史，不接受长期有效的 API Key
+		if !strings.HasPrefix(c.Authorization, "Bearer ") {
+			return nil, fmt.Errorf("%w: only access_token is allowed in query", ErrUnauthenticated)
+		}
+		return a.verifyJWT(strings.TrimPrefix(c.Authorization, "Bearer "), true)
+	}
+	switch {
+	case c.Identity != "":
+		return a.verifyForward(c.Identity, c.IdentitySignature)
+	case c.APIKey != "":
+		return a.verifyAPIKey(c.APIKey)
+	case c.Signature != "":
+		return a.verifyHMAC(c)
+	case strings.HasPrefix(c.Authorization, "Bearer "):
+		return a.verifyJWT(strings.TrimPrefix(c.Authorization, "Bearer "), false)
+	}
+	return nil, fmt.Errorf("%w: missing credential", ErrUnauthenticated)
+}
+
+func (a *Authenticator) verifyAPIKey(key string) (*Identity, error) {
+	for k, v := range a.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			return &Identity{Subject: v.Name, Tenant: v.Tenant, Method: MethodAPIKey}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: invalid api key", ErrUnauthenticated)
+}
+
+func (a *Authenticator) verifyHMAC(c *Credential) (*Identity, error) {
+	k, ok := a.hmac[c.KeyId]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrUnauthenticated, c.KeyId)
+	}
+	ts, err := strconv.ParseInt(c.Timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid timestamp", ErrUnauthenticated)
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > a.cfg.MaxSkew || skew < -a.cfg.MaxSkew {
+		return nil, fmt.Errorf("%w: timestamp expired", ErrUnauthenticated)
+	}
+	if c.Nonce == "" || len(c.Nonce) > maxNonce {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrUnauthenticated)
+	}
+	expected := Sign(k.Secret, c.Timestamp, c.Nonce, c.Method, c.Body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(c.Signature))) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrUnauthenticated)
+	}
+	// 签名有效期内同一 nonce 只能使用一次
+	if !a.nonces.use(c.KeyId+":"+c.Nonce, time.Unix(ts, 0).Add(a.cfg.MaxSkew)) {
+		return nil, fmt.Errorf("%w: nonce reused", ErrUnauthenticated)
+	}
+	return &Identity{Subject: k.KeyId, Tenant: k.Tenant, Method: MethodHMAC}, nil
+}
+
+// verifyJWT 校验 JWT，short 为 true 时剩余有效期不能超过 jwt.query_ttl
+func (a *Authenticator) verifyJWT(raw string, short bool) (*Identity, error) {
+	if a.jwks == nil {
+		return nil, fmt.Errorf("%w: jwt not enabled", ErrUnauthenticated)
+	}
+	opts := []jwt.ParserOption{jwt.WithExpirationRequired()}
+	if a.cfg.JWT.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.cfg.JWT.Issuer))
+	}
+	if a.cfg.JWT.Audience != "" {
+		opts = append(opts, jwt.WithAudience(a.cfg.JWT.Audience))
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(raw, claims, a.jwks.Keyfunc, opts...); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	if exp, _ := claims.GetExpirationTime(); short && time.Until(exp.Time) > a.cfg.JWT.QueryTTL {
+		return nil, fmt.Errorf("%w: access_token must expire within %s", ErrUnauthenticated, a.cfg.JWT.QueryTTL)
+	}
+	id := &Identity{Method: MethodJWT}
+	id.Subject, _ = claims.GetSubject()
+	if t, ok := claims[a.cfg.JWT.TenantClaim].(string); ok {
+		id.Tenant = t
+	}
+	return id, nil
+}
+
+// Forward 序列化并签名身份，供网关转发给 gRPC 服务
+func (a *Authenticator) Forward(id *Identity) (string, string) {
+	b, _ := json.Marshal(id)
+	s := hex.EncodeToString(b)
+	return s, a.internalSign(s)
+}
+
+func (a *Authenticator) verifyForward(identity, signature string) (*Identity, error) {
+	if !hmac.Equal([]byte(a.internalSign(identity)), []byte(signature)) {
+		return nil, fmt.Errorf("%w: invalid forwarded identity", ErrUnauthenticated)
+	}
+	b, err := hex.DecodeString(identity)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid forwarded identity", ErrUnauthenticated)
+	}
+	id := &Identity{}
+	if err = json.Unmarshal(b, id); err != nil {
+		return nil, fmt.Errorf("%w: invalid forwarded identity", ErrUnauthenticated)
+	}
+	return id, nil
+}
+
+func (a *Authenticator) internalSign(s string) string {
+	m := hmac.New(sha256.New, a.internal)
+	m.Write([]byte(s))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// Sign 计算 HMAC 签名：hex(hmac_sha256(secret, timestamp + "\n" + nonce + "\n" + method + "\n" + hex(sha256(body))))
+func Sign(secret, timestamp, nonce, method string, body []byte) string {
+	sum := sha256.Sum256(body)
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(timestamp + "\n" + nonce + "\n" + method + "\n" + hex.EncodeToString(sum[:])))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// maxNonce nonce 的最大长度
+const maxNonce = 64
+
+// nonceCache 记录签名有效期内已使用的 nonce，仅在本节点内防重放
+type nonceCache struct {
+	mu      sync.Mutex
+	seen    map[string]time.Time
+	sweepAt time.Time
+}
+
+// use 登记 nonce 直到 expireAt，已登记且未过期时返回 false
+func (c *nonceCache) use(key string, expireAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if now.After(c.sweepAt) {
+		for k, t := range c.seen {
+			if now.After(t) {
+				delete(c.seen, k)
+			}
+		}
+		c.sweepAt = now.Add(time.Minute)
+	}
+	if t, ok := c.seen[key]; ok && !now.After(t) {
+		return false
+	}
+	c.seen[key] = expireAt
+	return true
+}
+
+type identityCtxKey struct{}
+
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityCtxKey{}, id)
+}
+
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityCtxKey{}).(*Identity)
+	return id, ok
+}
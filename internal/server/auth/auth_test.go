@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAuthenticate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kid": "k1",
+		"kty": "RSA",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err = os.WriteFile(file, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := New(&Config{
+		Enable:   true,
+		APIKeys:  []*APIKey{{Name: "order", Key: "ak-1", Tenant: "order"}},
+		HMACKeys: []*HMACKey{{KeyId: "pay", Secret: "s3cr3t", Tenant: "pay"}},
+		JWT:      &JWTConfig{JWKSFile: file, Issuer: "sso"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := a.Authenticate(&Credential{APIKey: "ak-1"})
+	if err != nil || id.Tenant != "order" {
+		t.Fatalf("api key: %v %+v", err, id)
+	}
+	if _, err = a.Authenticate(&Credential{APIKey: "bad"}); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("api key: expected unauthenticated, got %v", err)
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	body := []byte(`{"schema":"FMT"}`)
+	c := &Credential{
+		KeyId:     "pay",
+		Timestamp: ts,
+		Nonce:     "n-1",
+		Method:    "POST /delay/register",
+		Body:      body,
+		Signature: Sign("s3cr3t", ts, "n-1", "POST /delay/register", body),
+	}
+	if id, err = a.Authenticate(c); err != nil || id.Tenant != "pay" {
+		t.Fatalf("hmac: %v %+v", err, id)
+	}
+	// 重放
+	if _, err = a.Authenticate(c); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("hmac: expected replay rejected, got %v", err)
+	}
+	unsigned := *c
+	unsigned.Nonce, unsigned.Signature = "", Sign("s3cr3t", ts, "", "POST /delay/register", body)
+	if _, err = a.Authenticate(&unsigned); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("hmac: expected missing nonce rejected, got %v", err)
+	}
+	c.Nonce, c.Signature = "n-2", Sign("s3cr3t", ts, "n-2", "POST /delay/register", body)
+	c.Body = []byte(`{"schema":"HTTP"}`)
+	if _, err = a.Authenticate(c); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("hmac: expected unauthenticated, got %v", err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub":    "user-1",
+		"iss":    "sso",
+		"tenant": "crm",
+		"exp":    time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = "k1"
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err = a.Authenticate(&Credential{Authorization: "Bearer " + raw}); err != nil || id.Tenant != "crm" || id.Subject != "user-1" {
+		t.Fatalf("jwt: %v %+v", err, id)
+	}
+
+	// 查询参数仅接受短期 JWT
+	if id, err = a.Authenticate(&Credential{Authorization: "Bearer " + raw, Query: true}); err != nil || id.Tenant != "crm" {
+		t.Fatalf("query jwt: %v %+v", err, id)
+	}
+	if _, err = a.Authenticate(&Credential{APIKey: "ak-1", Query: true}); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("query api key: expected unauthenticated, got %v", err)
+	}
+	long := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub": "user-1",
+		"iss": "sso",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	long.Header["kid"] = "k1"
+	longRaw, _ := long.SignedString(key)
+	if _, err = a.Authenticate(&Credential{Authorization: "Bearer " + longRaw, Query: true}); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("query jwt: expected long-lived token rejected, got %v", err)
+	}
+
+	identity, signature := a.Forward(id)
+	if id, err = a.Authenticate(&Credential{Identity: identity, IdentitySignature: signature}); err != nil || id.Tenant != "crm" {
+		t.Fatalf("forward: %v %+v", err, id)
+	}
+	if _, err = a.Authenticate(&Credential{Identity: identity, IdentitySignature: "forged"}); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("forward: expected unauthenticated, got %v", err)
+	}
+}
+
+func TestDefaultTenant(t *testing.T) {
+	cfg := &Config{
+		Enable:  true,
+		APIKeys: []*APIKey{{Name: "ops", Key: "ak-ops"}, {Name: "order", Key: "ak-1", Tenant: "order"}},
+	}
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := a.Authenticate(&Credential{APIKey: "ak-ops"}); err != nil || id.Tenant != "" {
+		t.Fatalf("expected empty tenant: %v %+v", err, id)
+	}
+
+	// 默认值不写回调用方的配置
+	if cfg.MaxSkew != 0 {
+		t.Fatalf("config modified: %+v", cfg)
+	}
+
+	cfg.DefaultTenant = "default"
+	if a, err = New(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := a.Authenticate(&Credential{APIKey: "ak-ops"}); err != nil || id.Tenant != "default" {
+		t.Fatalf("expected default tenant: %v %+v", err, id)
+	}
+	if id, err := a.Authenticate(&Credential{APIKey: "ak-1"}); err != nil || id.Tenant != "order" {
+		t.Fatalf("expected order tenant: %v %+v", err, id)
+	}
+}
@@ -7,14 +7,18 @@ import (
 
 	pbdelay "github.com/x-thooh/delay/api/delay"
 	pbexample "github.com/x-thooh/delay/api/example"
+	"github.com/x-thooh/delay/internal/server/auth"
 	middleware "github.com/x-thooh/delay/internal/server/grpc/middleware"
 	"github.com/x-thooh/delay/pkg/log"
+	"github.com/x-thooh/delay/pkg/tlsx"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 type Server struct {
 	cfg           *Config
 	lg            log.Logger
+	auth          *auth.Authenticator
 	gs            *grpc.Server
 	delayServer   pbdelay.DelayServer
 	exampleServer pbexample.ExampleServer
@@ -23,12 +27,14 @@ type Server struct {
 func New(
 	cfg *Config,
 	lg log.Logger,
+	a *auth.Authenticator,
 	delayServer pbdelay.DelayServer,
 	exampleServer pbexample.ExampleServer,
 ) *Server {
 	s := &Server{
 		cfg:           cfg,
 		lg:            lg,
+		auth:          a,
 		delayServer:   delayServer,
 		exampleServer: exampleServer,
 	}
@@ -36,8 +42,9 @@ func New(
 }
 
 type Config struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
+	Host string       `yaml:"host"`
+	Port int          `yaml:"port"`
+	TLS  *tlsx.Config `yaml:"tls"`
 }
 
 func (s *Server) Start(_ context.Context) error {
@@ -46,16 +53,28 @@ func (s *Server) Start(_ context.Context) error {
 		return err
 	}
 
-	s.gs = grpc.NewServer(
+	opts := []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(
 			middleware.UnaryServerLogInterceptor(s.lg),
+			middleware.UnaryServerAuthInterceptor(s.lg, s.auth),
+			middleware.UnaryServerTenantInterceptor(s.auth),
 			middleware.UnaryServerValidatorInterceptor(s.lg),
 		),
 		grpc.ChainStreamInterceptor(
 			middleware.StreamServerLogInterceptor(s.lg),
+			middleware.StreamServerAuthInterceptor(s.lg, s.auth),
+			middleware.StreamServerTenantInterceptor(s.auth),
 			middleware.StreamServerValidatorInterceptor(s.lg),
 		),
-	)
+	}
+	if s.cfg.TLS.Enabled() {
+		tc, err := s.cfg.TLS.ServerConfig()
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tc)))
+	}
+	s.gs = grpc.NewServer(opts...)
 	pbdelay.RegisterDelayServer(s.gs, s.delayServer)
 	pbexample.RegisterExampleServer(s.gs, s.exampleServer)
 
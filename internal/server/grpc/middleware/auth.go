@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/x-thooh/delay/internal/server/auth"
+	"github.com/x-thooh/delay/pkg/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryServerAuthInterceptor 认证调用方，并将身份写入上下文
+func UnaryServerAuthInterceptor(logger log.Logger, a *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if a.Skip(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		c := credentialFromMetadata(ctx, info.FullMethod)
+		if msg, ok := req.(proto.Message); ok && c.Signature != "" {
+			body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			c.Body = body
+		}
+		ctx, err := authenticate(ctx, logger, a, c)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerAuthInterceptor(logger log.Logger, a *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if a.Skip(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		// 流式请求不支持 HMAC 消息体签名
+		ctx, err := authenticate(ss.Context(), logger, a, credentialFromMetadata(ss.Context(), info.FullMethod))
+		if err != nil {
+			return err
+		}
+		return handler(srv, &wrappedServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, logger log.Logger, a *auth.Authenticator, c *auth.Credential) (context.Context, error) {
+	id, err := a.Authenticate(c)
+	if err != nil {
+		logger.Error(ctx, "认证失败", "method", c.Method, "error", err.Error())
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return auth.NewContext(ctx, id), nil
+}
+
+func credentialFromMetadata(ctx context.Context, method string) *auth.Credential {
+	md, _ := metadata.FromIncomingContext(ctx)
+	get := func(key string) string {
+		if vs := md.Get(strings.ToLower(key)); len(vs) > 0 {
+			return vs[0]
+		}
+		return ""
+	}
+	return &auth.Credential{
+		APIKey:            get(auth.HeaderAPIKey),
+		Authorization:     get(auth.HeaderAuthorization),
+		KeyId:             get(auth.HeaderKeyId),
+		Timestamp:         get(auth.HeaderTimestamp),
+		Nonce:             get(auth.HeaderNonce),
+		Signature:         get(auth.HeaderSignature),
+		Method:            method,
+		Identity:          get(auth.HeaderIdentity),
+		IdentitySignature: get(auth.HeaderIdentitySignature),
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/x-thooh/delay/internal/server/auth"
+	"github.com/x-thooh/delay/pkg/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerTenantInterceptor 确定请求的租户，需在认证之后执行：
+// 开启认证时租户只取自认证身份，未开启认证时取元数据中的租户
+func UnaryServerTenantInterceptor(a *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, err := withTenant(ctx, a, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerTenantInterceptor(a *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := withTenant(ss.Context(), a, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &wrappedServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func withTenant(ctx context.Context, a *auth.Authenticator, method string) (context.Context, error) {
+	if a == nil {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if ts := md.Get(strings.ToLower(tenant.GetHeaderKey())); len(ts) > 0 {
+				ctx = tenant.Set(ctx, ts[0])
+			}
+		}
+		return ctx, nil
+	}
+	if a.Skip(method) {
+		return ctx, nil
+	}
+	id, ok := auth.FromContext(ctx)
+	if !ok || id.Tenant == "" {
+		return ctx, status.Error(codes.PermissionDenied, "identity has no tenant")
+	}
+	return tenant.Set(ctx, id.Tenant), nil
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/x-thooh/delay/internal/server/auth"
+	"github.com/x-thooh/delay/pkg/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerTenantInterceptor(t *testing.T) {
+	a, err := auth.New(&auth.Config{
+		Enable: true,
+		Skip:   []string{"/delay.Delay/Health"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return tenant.Get(ctx), nil
+	}
+	header := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenant.GetHeaderKey(), "spoofed"))
+
+	tests := []struct {
+		name   string
+		a      *auth.Authenticator
+		ctx    context.Context
+		method string
+		want   string
+		code   codes.Code
+	}{
+		{"auth disabled uses header", nil, header, "/delay.Delay/Register", "spoofed", codes.OK},
+		{"identity tenant", a, auth.NewContext(header, &auth.Identity{Subject: "order", Tenant: "order"}), "/delay.Delay/Register", "order", codes.OK},
+		{"identity without tenant", a, auth.NewContext(header, &auth.Identity{Subject: "ops"}), "/delay.Delay/Register", "", codes.PermissionDenied},
+		{"no identity", a, header, "/delay.Delay/Register", "", codes.PermissionDenied},
+		{"skipped method ignores header", a, header, "/delay.Delay/Health", "", codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnaryServerTenantInterceptor(tt.a)(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("expected code %v, got %v", tt.code, err)
+			}
+			if err == nil && got != tt.want {
+				t.Fatalf("expected tenant %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// registerAdmin 注册运维接口
+func (s *Server) registerAdmin(mux *runtime.ServeMux) error {
+	return mux.HandlePath(http.MethodGet, "/admin/breakers", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		writeJSON(w, http.StatusOK, map[string]any{"breakers": s.storage.Breakers()})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/x-thooh/delay/internal/server/auth"
+	"google.golang.org/grpc/metadata"
+)
+
+// authHandler 认证 HTTP 请求，认证后的身份由网关签名转发给 gRPC 服务
+func (s *Server) authHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.auth.Skip(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		c := &auth.Credential{
+			APIKey:        r.Header.Get(auth.HeaderAPIKey),
+			Authorization: r.Header.Get(auth.HeaderAuthorization),
+			KeyId:         r.Header.Get(auth.HeaderKeyId),
+			Timestamp:     r.Header.Get(auth.HeaderTimestamp),
+			Nonce:         r.Header.Get(auth.HeaderNonce),
+			Signature:     r.Header.Get(auth.HeaderSignature),
+			Method:        r.Method + " " + r.URL.RequestURI(),
+		}
+		if strings.HasPrefix(r.URL.Path, "/push/") && c.APIKey == "" && c.Authorization == "" {
+			// 浏览器的 WebSocket、EventSource 无法设置请求头，订阅接口允许通过查询参数中的短期 access_token 认证
+			if token := r.URL.Query().Get("access_token"); token != "" {
+				c.Authorization, c.Query = "Bearer "+token, true
+			}
+		}
+		if c.Signature != "" && r.Body != nil {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"message": err.Error()})
+				return
+			}
+			c.Body = body
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		id, err := s.auth.Authenticate(c)
+		if err != nil {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"message": err.Error()})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), id)))
+	})
+}
+
+// forwardIdentity 将已认证身份写入 gRPC 元数据
+func (s *Server) forwardIdentity(ctx context.Context, _ *http.Request) metadata.MD {
+	id, ok := auth.FromContext(ctx)
+	if !ok || s.auth == nil {
+		return nil
+	}
+	identity, signature := s.auth.Forward(id)
+	return metadata.Pairs(auth.HeaderIdentity, identity, auth.HeaderIdentitySignature, signature)
+}
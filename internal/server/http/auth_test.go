@@ -0,0 +1,100 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/x-thooh/delay/internal/server/auth"
+)
+
+func TestAuthHandler(t *testing.T) {
+	a, err := auth.New(&auth.Config{
+		Enable:   true,
+		APIKeys:  []*auth.APIKey{{Name: "order", Key: "ak-1", Tenant: "order"}},
+		HMACKeys: []*auth.HMACKey{{KeyId: "pay", Secret: "s3cr3t", Tenant: "pay"}},
+		Skip:     []string{"/example/valid"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(&Config{}, a, nil, nil)
+	srv := httptest.NewServer(s.authHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		tenant := "-"
+		if id, ok := auth.FromContext(r.Context()); ok {
+			tenant = id.Tenant
+		}
+		_, _ = w.Write([]byte(tenant + " " + string(body)))
+	})))
+	defer srv.Close()
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	body := `{"schema":"FMT"}`
+	signed := func(nonce string) map[string]string {
+		return map[string]string{
+			auth.HeaderKeyId:     "pay",
+			auth.HeaderTimestamp: ts,
+			auth.HeaderNonce:     nonce,
+			auth.HeaderSignature: auth.Sign("s3cr3t", ts, nonce, "POST /delay/register", []byte(body)),
+		}
+	}
+	tests := []struct {
+		name   string
+		path   string
+		header map[string]string
+		code   int
+		want   string
+	}{
+		{"api key", "/delay/register", map[string]string{auth.HeaderAPIKey: "ak-1"}, http.StatusOK, "order " + body},
+		{"invalid api key", "/delay/register", map[string]string{auth.HeaderAPIKey: "bad"}, http.StatusUnauthorized, ""},
+		{"missing credential", "/delay/register", nil, http.StatusUnauthorized, ""},
+		{"hmac keeps body", "/delay/register", signed("n-1"), http.StatusOK, "pay " + body},
+		{"hmac replay", "/delay/register", signed("n-1"), http.StatusUnauthorized, ""},
+		{"skipped", "/example/valid", nil, http.StatusOK, "- " + body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+tt.path, strings.NewReader(body))
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			got, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.code {
+				t.Fatalf("expected %d, got %d %s", tt.code, resp.StatusCode, got)
+			}
+			if tt.want != "" && string(got) != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestForwardIdentity(t *testing.T) {
+	a, err := auth.New(&auth.Config{Enable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(&Config{}, a, nil, nil)
+	if md := s.forwardIdentity(context.Background(), nil); md != nil {
+		t.Fatalf("unexpected metadata %v", md)
+	}
+	md := s.forwardIdentity(auth.NewContext(context.Background(), &auth.Identity{Subject: "order", Tenant: "order"}), nil)
+	id, err := a.Authenticate(&auth.Credential{
+		Identity:          md.Get(auth.HeaderIdentity)[0],
+		IdentitySignature: md.Get(auth.HeaderIdentitySignature)[0],
+	})
+	if err != nil || id.Tenant != "order" {
+		t.Fatalf("unexpected %v %+v", err, id)
+	}
+}
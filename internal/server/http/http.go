@@ -4,76 +4,129 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	pbdelay "github.com/x-thooh/delay/api/delay"
 	pbexample "github.com/x-thooh/delay/api/example"
+	"github.com/x-thooh/delay/internal/server/auth"
+	"github.com/x-thooh/delay/internal/service/push"
+	"github.com/x-thooh/delay/internal/service/storage"
+	"github.com/x-thooh/delay/pkg/tenant"
+	"github.com/x-thooh/delay/pkg/tlsx"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
 type Server struct {
-	cfg *Config
-	srv *http.Server
+	cfg     *Config
+	srv     *http.Server
+	auth    *auth.Authenticator
+	storage *storage.Storage
+	hub     *push.Hub
 }
 
 type Config struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
+	Host string       `yaml:"host"`
+	Port int          `yaml:"port"`
+	TLS  *tlsx.Config `yaml:"tls"`
 
-	GHost string `yaml:"ghost"`
-	GPort int    `yaml:"gport"`
+	GHost string       `yaml:"ghost"`
+	GPort int          `yaml:"gport"`
+	GTLS  *tlsx.Config `yaml:"gtls"`
+
+	Push *PushConfig `yaml:"push"`
 }
 
 func New(
 	cfg *Config,
+	a *auth.Authenticator,
+	storage *storage.Storage,
+	hub *push.Hub,
 ) *Server {
 	s := &Server{
-		cfg: cfg,
+		cfg:     cfg,
+		auth:    a,
+		storage: storage,
+		hub:     hub,
 	}
 	return s
 }
 
 func (s *Server) Start(ctx context.Context) error {
-	mux := runtime.NewServeMux(runtime.WithMarshalerOption(runtime.MIMEWildcard, &plainTextMarshaler{
-		JSONPb: runtime.JSONPb{
-			MarshalOptions: protojson.MarshalOptions{
-				UseProtoNames:   true, // 原来 OrigName
-				EmitUnpopulated: true, // 原来 EmitDefaults
-			},
-			UnmarshalOptions: protojson.UnmarshalOptions{
-				DiscardUnknown: true,
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &plainTextMarshaler{
+			JSONPb: runtime.JSONPb{
+				MarshalOptions: protojson.MarshalOptions{
+					UseProtoNames:   true, // 原来 OrigName
+					EmitUnpopulated: true, // 原来 EmitDefaults
+				},
+				UnmarshalOptions: protojson.UnmarshalOptions{
+					DiscardUnknown: true,
+				},
 			},
-		},
-	}))
+		}),
+		runtime.WithIncomingHeaderMatcher(s.headerMatcher),
+		runtime.WithMetadata(s.forwardIdentity),
+	)
 
 	// 连接到 gRPC 后端
+	creds := insecure.NewCredentials()
+	if s.cfg.GTLS.Enabled() {
+		tc, err := s.cfg.GTLS.ClientConfig()
+		if err != nil {
+			return err
+		}
+		creds = credentials.NewTLS(tc)
+	}
 	err := pbdelay.RegisterDelayHandlerFromEndpoint(
 		ctx, mux, fmt.Sprintf("%s:%d", s.cfg.GHost, s.cfg.GPort),
-		[]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		[]grpc.DialOption{grpc.WithTransportCredentials(creds)},
 	)
 	if err != nil {
 		return err
 	}
 	err = pbexample.RegisterExampleHandlerFromEndpoint(
 		ctx, mux, fmt.Sprintf("%s:%d", s.cfg.GHost, s.cfg.GPort),
-		[]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		[]grpc.DialOption{grpc.WithTransportCredentials(creds)},
 	)
 	if err != nil {
 		return err
 	}
 
+	if err = s.registerAdmin(mux); err != nil {
+		return err
+	}
+	if err = s.registerPush(mux); err != nil {
+		return err
+	}
+
 	// 创建 http.Server
 	s.srv = &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", s.cfg.Host, s.cfg.Port),
-		Handler: mux,
+		Handler: s.authHandler(mux),
 	}
 
 	// 运行 HTTP 服务（阻塞）
+	if s.cfg.TLS.Enabled() {
+		if s.srv.TLSConfig, err = s.cfg.TLS.ServerConfig(); err != nil {
+			return err
+		}
+		return s.srv.ListenAndServeTLS("", "")
+	}
 	return s.srv.ListenAndServe()
 }
 
+// headerMatcher 未开启认证时透传租户请求头到 gRPC 元数据，开启认证时租户只取自认证身份
+func (s *Server) headerMatcher(key string) (string, bool) {
+	if s.auth == nil && strings.EqualFold(key, tenant.GetHeaderKey()) {
+		return strings.ToLower(key), true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
 func (s *Server) Stop(ctx context.Context) error {
 	if s.srv != nil {
 		// 优雅关闭，等待正在处理的请求完成
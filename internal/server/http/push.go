@@ -0,0 +1,224 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/x-thooh/delay/internal/server/auth"
+	"github.com/x-thooh/delay/internal/service/push"
+	"github.com/x-thooh/delay/pkg/tenant"
+)
+
+// PushConfig 推送订阅接口
+type PushConfig struct {
+	Enable bool `yaml:"enable"`
+	// 允许的 WebSocket Origin，为空时仅允许同源，* 允许所有
+	Origins []string `yaml:"origins"`
+	// 每个订阅者未读消息上限，默认 64
+	Buffer int `yaml:"buffer"`
+	// 心跳间隔，默认 25s
+	Ping time.Duration `yaml:"ping"`
+	// 单个连接最多订阅的频道数，默认 16
+	MaxChannels int `yaml:"max_channels"`
+}
+
+func (c *PushConfig) buffer() int {
+	if c.Buffer <= 0 {
+		return 64
+	}
+	return c.Buffer
+}
+
+func (c *PushConfig) ping() time.Duration {
+	if c.Ping <= 0 {
+		return 25 * time.Second
+	}
+	return c.Ping
+}
+
+func (c *PushConfig) maxChannels() int {
+	if c.MaxChannels <= 0 {
+		return 16
+	}
+	return c.MaxChannels
+}
+
+var errPushTenant = errors.New("authenticated tenant is required")
+
+// registerPush 注册 WebSocket 与 SSE 订阅接口
+func (s *Server) registerPush(mux *runtime.ServeMux) error {
+	if s.cfg.Push == nil || !s.cfg.Push.Enable {
+		return nil
+	}
+	if err := mux.HandlePath(http.MethodGet, "/push/sse", s.handleSSE); err != nil {
+		return err
+	}
+	return mux.HandlePath(http.MethodGet, "/push/ws", s.handleWebSocket)
+}
+
+// subscribe 按请求中的频道及租户订阅
+func (s *Server) subscribe(r *http.Request) (*push.Subscriber, error) {
+	q := r.URL.Query()
+	channels := q["channel"]
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("channel is required")
+	}
+	if len(channels) > s.cfg.Push.maxChannels() {
+		return nil, fmt.Errorf("at most %d channels", s.cfg.Push.maxChannels())
+	}
+
+	tn, err := s.pushTenant(r)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		if ch == "" {
+			return nil, fmt.Errorf("channel is required")
+		}
+		keys = append(keys, push.Key(tn, ch))
+	}
+	return s.hub.Subscribe(s.cfg.Push.buffer(), keys...), nil
+}
+
+// pushTenant 订阅的租户：开启认证时只取认证身份，未开启时取请求头或查询参数
+func (s *Server) pushTenant(r *http.Request) (string, error) {
+	if s.auth == nil {
+		if tn := r.Header.Get(tenant.GetHeaderKey()); tn != "" {
+			return tn, nil
+		}
+		return r.URL.Query().Get("tenant"), nil
+	}
+	id, ok := auth.FromContext(r.Context())
+	if !ok || id.Tenant == "" {
+		return "", errPushTenant
+	}
+	return id.Tenant, nil
+}
+
+// subscribeError 订阅失败的响应
+func subscribeError(w http.ResponseWriter, err error) {
+	code := http.StatusBadRequest
+	if errors.Is(err, errPushTenant) {
+		code = http.StatusForbidden
+	}
+	writeJSON(w, code, map[string]any{"message": err.Error()})
+}
+
+func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	sub, err := s.subscribe(r)
+	if err != nil {
+		subscribeError(w, err)
+		return
+	}
+	defer s.hub.Unsubscribe(sub)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err = fmt.Fprint(w, ": subscribed\n\n"); err != nil || rc.Flush() != nil {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Push.ping())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		case msg, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			var b []byte
+			if b, err = json.Marshal(msg); err != nil {
+				return
+			}
+			_, err = fmt.Fprintf(w, "id: %s\nevent: task\ndata: %s\n\n", strconv.FormatInt(msg.TaskNo, 10), b)
+		}
+		if err != nil || rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	sub, err := s.subscribe(r)
+	if err != nil {
+		subscribeError(w, err)
+		return
+	}
+	defer s.hub.Unsubscribe(sub)
+
+	upgrader := &websocket.Upgrader{CheckOrigin: s.checkOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	ping := s.cfg.Push.ping()
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	// 读取客户端消息以处理 pong 与关闭帧，超过两个心跳周期无响应则断开
+	go func() {
+		defer cancel()
+		_ = conn.SetReadDeadline(time.Now().Add(2 * ping))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * ping))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(ping)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(ping))
+		case msg, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(ping))
+			err = conn.WriteJSON(msg)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	origins := s.cfg.Push.Origins
+	if len(origins) == 0 {
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+	return slices.Contains(origins, "*") || slices.Contains(origins, origin)
+}
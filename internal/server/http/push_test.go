@@ -0,0 +1,204 @@
+package http
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/x-thooh/delay/internal/server/auth"
+	"github.com/x-thooh/delay/internal/service/push"
+)
+
+func newPushServer(t *testing.T) (*httptest.Server, *push.Hub) {
+	return newAuthPushServer(t, nil)
+}
+
+func newAuthPushServer(t *testing.T, a *auth.Authenticator) (*httptest.Server, *push.Hub) {
+	hub := push.NewHub()
+	s := New(&Config{Push: &PushConfig{Enable: true}}, a, nil, hub)
+	mux := runtime.NewServeMux()
+	if err := s.registerPush(mux); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.authHandler(mux))
+	t.Cleanup(srv.Close)
+	return srv, hub
+}
+
+// waitSubscribed 等待订阅建立
+func waitSubscribed(t *testing.T, hub *push.Hub, key string) {
+	for i := 0; i < 100; i++ {
+		if hub.Count(key) > 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("subscriber not registered")
+}
+
+func TestPushSSE(t *testing.T) {
+	srv, hub := newPushServer(t)
+
+	resp, err := http.Get(srv.URL + "/push/sse?channel=countdown&tenant=order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	waitSubscribed(t, hub, push.Key("order", "countdown"))
+	hub.Publish(push.Key("order", "countdown"), &push.Message{Channel: "countdown", TaskNo: 42, Data: []byte(`{"a":1}`)})
+
+	r := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, ":") {
+			lines = append(lines, line)
+		}
+	}
+	if lines[0] != "id: 42" || lines[1] != "event: task" || !strings.Contains(lines[2], `"data":{"a":1}`) {
+		t.Fatalf("unexpected event %q", lines)
+	}
+
+	if resp, err = http.Get(srv.URL + "/push/sse"); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+}
+
+func TestPushWebSocket(t *testing.T) {
+	srv, hub := newPushServer(t)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/push/ws?channel=countdown"
+
+	// 默认仅允许同源
+	if _, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"http://evil.example"}}); err == nil {
+		t.Fatal("expected cross origin rejected")
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"X-Tenant-Id": {"order"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	waitSubscribed(t, hub, push.Key("order", "countdown"))
+	hub.Publish(push.Key("order", "countdown"), &push.Message{Channel: "countdown", TaskNo: 42, Data: []byte(`{"a":1}`)})
+
+	var msg push.Message
+	if err = conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.TaskNo != 42 || string(msg.Data) != `{"a":1}` {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+
+	conn.Close()
+	for i := 0; i < 100 && hub.Count(push.Key("order", "countdown")) > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := hub.Count(push.Key("order", "countdown")); n != 0 {
+		t.Fatalf("subscriber not removed: %d", n)
+	}
+}
+
+func TestPushAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kid": "k1",
+		"kty": "RSA",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err = os.WriteFile(file, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := auth.New(&auth.Config{
+		Enable:  true,
+		APIKeys: []*auth.APIKey{{Name: "order", Key: "ak-1", Tenant: "order"}, {Name: "ops", Key: "ak-2"}},
+		JWT:     &auth.JWTConfig{JWKSFile: file},
+		Skip:    []string{"/push/ws"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, hub := newAuthPushServer(t, a)
+	token := func(ttl time.Duration) string {
+		tk := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"sub":    "user-1",
+			"tenant": "crm",
+			"exp":    time.Now().Add(ttl).Unix(),
+		})
+		tk.Header["kid"] = "k1"
+		raw, err := tk.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		header http.Header
+		code   int
+	}{
+		{"api key in query", "/push/sse?channel=c&api_key=ak-1", nil, http.StatusUnauthorized},
+		{"long-lived access token", "/push/sse?channel=c&access_token=" + token(time.Hour), nil, http.StatusUnauthorized},
+		{"identity without tenant", "/push/sse?channel=c", http.Header{auth.HeaderAPIKey: {"ak-2"}}, http.StatusForbidden},
+		{"unauthenticated", "/push/ws?channel=c&tenant=order", http.Header{"X-Tenant-Id": {"order"}}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			req.Header = tt.header
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.code {
+				t.Fatalf("expected %d, got %d", tt.code, resp.StatusCode)
+			}
+		})
+	}
+
+	// 租户只取认证身份，忽略请求中的租户
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/push/sse?channel=c&tenant=order&access_token="+token(time.Minute), nil)
+	req.Header.Set("X-Tenant-Id", "order")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+	waitSubscribed(t, hub, push.Key("crm", "c"))
+	if n := hub.Count(push.Key("order", "c")); n != 0 {
+		t.Fatalf("unexpected subscribers for request tenant: %d", n)
+	}
+}
@@ -2,11 +2,13 @@ package server
 
 import (
 	"github.com/google/wire"
+	"github.com/x-thooh/delay/internal/server/auth"
 	"github.com/x-thooh/delay/internal/server/grpc"
 	"github.com/x-thooh/delay/internal/server/http"
 )
 
 var ProviderSetServer = wire.NewSet(
+	auth.New,
 	http.New,
 	grpc.New,
 )
@@ -2,10 +2,16 @@ package delay
 
 import (
 	"context"
+	"errors"
 
 	pbdelay "github.com/x-thooh/delay/api/delay"
 	"github.com/x-thooh/delay/internal/service/storage"
 	"github.com/x-thooh/delay/internal/service/storage/callback"
+	"github.com/x-thooh/delay/internal/service/storage/quota"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type service struct {
@@ -29,16 +35,98 @@ func (s *service) Register(ctx context.Context, request *pbdelay.RegisterRequest
 		storage.WithDelayTime(request.GetDelayTime()),
 		storage.WithTimeout(request.GetTimeout()),
 		storage.WithBackoff(request.GetBackoff()...),
+		storage.WithParents(request.GetParents()...),
+		storage.WithParentFailure(request.GetOnParentFailure()),
 
 		storage.WithPayload(&callback.Payload{
 			Schema: request.GetSchema(),
 			Url:    request.GetUrl(),
 			Path:   request.GetPath(),
 			Data:   request.GetData().AsMap(),
+			Secret: request.GetSecret(),
+
+			Method:      request.GetMethod(),
+			Headers:     request.GetHeaders(),
+			Query:       request.GetQuery(),
+			ContentType: request.GetContentType(),
+			Template:    request.GetTemplate(),
+
+			Key:  request.GetKey(),
+			Mode: request.GetMode(),
+
+			OnComplete: toPayload(request.GetOnComplete()),
 		}),
 	)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 	return &pbdelay.RegisterReply{TaskNo: tn}, nil
 }
+
+func toPayload(cb *pbdelay.Callback) *callback.Payload {
+	if cb == nil {
+		return nil
+	}
+	return &callback.Payload{
+		Schema: cb.GetSchema(),
+		Url:    cb.GetUrl(),
+		Path:   cb.GetPath(),
+		Data:   cb.GetData().AsMap(),
+		Secret: cb.GetSecret(),
+
+		Method:      cb.GetMethod(),
+		Headers:     cb.GetHeaders(),
+		Query:       cb.GetQuery(),
+		ContentType: cb.GetContentType(),
+		Template:    cb.GetTemplate(),
+
+		Key:  cb.GetKey(),
+		Mode: cb.GetMode(),
+	}
+}
+
+func (s *service) Query(ctx context.Context, request *pbdelay.QueryRequest) (*pbdelay.QueryReply, error) {
+	task, err := s.storage.Get(ctx, request.GetTaskNo())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	reply := &pbdelay.QueryReply{
+		TaskNo:    task.TaskNo,
+		Tenant:    task.Tenant,
+		Status:    int32(task.Status),
+		FailCount: int32(task.FailCount),
+		NextRunAt: timestamppb.New(task.NextRunAt),
+		CreatedAt: timestamppb.New(task.CreatedAt),
+		UpdatedAt: timestamppb.New(task.UpdatedAt),
+	}
+	if p := task.Payload; p != nil {
+		reply.Schema, reply.Url, reply.Path = p.Schema, p.Url, p.Path
+		if reply.Data, err = structpb.NewStruct(p.Data); err != nil {
+			return nil, err
+		}
+	}
+	if task.Result != nil {
+		reply.Result = *task.Result
+	}
+	return reply, nil
+}
+
+func (s *service) Cancel(ctx context.Context, request *pbdelay.CancelRequest) (*pbdelay.CancelReply, error) {
+	canceled, err := s.storage.Cancel(ctx, request.GetTaskNo())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pbdelay.CancelReply{Canceled: canceled}, nil
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, storage.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, quota.ErrExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+// Package push 推送订阅：客户端通过 WebSocket 或 SSE 订阅频道，PUSH 回调将到期任务推送给频道的在线订阅者。
+//
+// 订阅仅在所连接的节点内有效，多节点部署时需保证订阅者与执行任务的节点一致（如按频道做会话保持），
+// 否则任务会因没有订阅者而按失败重试。
+package push
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Key 订阅键，频道按租户隔离
+func Key(tenant, channel string) string {
+	return tenant + "\x00" + channel
+}
+
+// Message 推送给订阅者的任务
+type Message struct {
+	Channel     string          `json:"channel"`
+	TaskNo      int64           `json:"task_no,string"`
+	TraceId     string          `json:"trace_id,omitempty"`
+	ContentType string          `json:"content_type"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// Subscriber 订阅者，C 关闭表示订阅已结束
+type Subscriber struct {
+	C <-chan *Message
+
+	c    chan *Message
+	keys []string
+}
+
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[string]map[*Subscriber]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[string]map[*Subscriber]struct{})}
+}
+
+// Subscribe 订阅 Key 生成的订阅键，buffer 为未读消息上限，超出后新消息对该订阅者丢弃
+func (h *Hub) Subscribe(buffer int, keys ...string) *Subscriber {
+	c := make(chan *Message, buffer)
+	s := &Subscriber{C: c, c: c, keys: keys}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range keys {
+		m, ok := h.subs[key]
+		if !ok {
+			m = make(map[*Subscriber]struct{})
+			h.subs[key] = m
+		}
+		m[s] = struct{}{}
+	}
+	return s
+}
+
+// Unsubscribe 取消订阅并关闭 s.C
+func (h *Hub) Unsubscribe(s *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s.keys == nil {
+		return
+	}
+	for _, key := range s.keys {
+		if m, ok := h.subs[key]; ok {
+			delete(m, s)
+			if len(m) == 0 {
+				delete(h.subs, key)
+			}
+		}
+	}
+	s.keys = nil
+	close(s.c)
+}
+
+// Publish 推送给订阅键的所有订阅者，返回成功投递的订阅者数
+func (h *Hub) Publish(key string, msg *Message) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	n := 0
+	for s := range h.subs[key] {
+		select {
+		case s.c <- msg:
+			n++
+		default:
+		}
+	}
+	return n
+}
+
+// Count 订阅键的在线订阅者数
+func (h *Hub) Count(key string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs[key])
+}
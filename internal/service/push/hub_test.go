@@ -0,0 +1,37 @@
+package push
+
+import "testing"
+
+func TestHub(t *testing.T) {
+	h := NewHub()
+	a := h.Subscribe(1, Key("order", "countdown"))
+	b := h.Subscribe(1, Key("order", "countdown"), Key("order", "other"))
+	other := h.Subscribe(1, Key("shop", "countdown"))
+
+	if n := h.Publish(Key("order", "countdown"), &Message{TaskNo: 1}); n != 2 {
+		t.Fatalf("unexpected deliveries %d", n)
+	}
+	if len(other.C) != 0 {
+		t.Fatal("message delivered across tenants")
+	}
+	// 缓冲已满的订阅者丢弃新消息
+	if n := h.Publish(Key("order", "countdown"), &Message{TaskNo: 2}); n != 0 {
+		t.Fatalf("unexpected deliveries %d", n)
+	}
+	if msg := <-a.C; msg.TaskNo != 1 {
+		t.Fatalf("unexpected message %d", msg.TaskNo)
+	}
+
+	h.Unsubscribe(b)
+	h.Unsubscribe(b)
+	<-b.C
+	if _, ok := <-b.C; ok {
+		t.Fatal("expected closed channel")
+	}
+	if n := h.Count(Key("order", "other")); n != 0 {
+		t.Fatalf("unexpected subscribers %d", n)
+	}
+	if n := h.Publish(Key("order", "countdown"), &Message{TaskNo: 3}); n != 1 {
+		t.Fatalf("unexpected deliveries %d", n)
+	}
+}
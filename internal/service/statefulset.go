@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/x-thooh/delay/pkg/log"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// StatefulSetPod 监听 StatefulSet 中 Ready 的 Pod 序号
+type StatefulSetPod struct {
+	cs        kubernetes.Interface
+	lg        log.Logger
+	namespace string
+	stsName   string
+
+	resync   time.Duration
+	debounce time.Duration
+	retry    time.Duration
+}
+
+type StatefulSetOption func(*StatefulSetPod)
+
+// WithResync informer 全量同步周期，默认 30s
+func WithResync(d time.Duration) StatefulSetOption {
+	return func(ss *StatefulSetPod) {
+		if d > 0 {
+			ss.resync = d
+		}
+	}
+}
+
+// WithDebounce Pod 变化后等待该时间内没有新的变化再通知，默认 1s
+func WithDebounce(d time.Duration) StatefulSetOption {
+	return func(ss *StatefulSetPod) {
+		if d > 0 {
+			ss.debounce = d
+		}
+	}
+}
+
+func NewStatefulSetPod(cs kubernetes.Interface, lg log.Logger, namespace, stsName string, opts ...StatefulSetOption) *StatefulSetPod {
+	ss := &StatefulSetPod{
+		cs:        cs,
+		lg:        lg,
+		namespace: namespace,
+		stsName:   stsName,
+		resync:    30 * time.Second,
+		debounce:  time.Second,
+		retry:     5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(ss)
+	}
+	return ss
+}
+
+// Run 监听 Pod 变化，Ready 的 Pod 序号（升序）变化时调用 fn，直到 ctx 结束
+func (ss *StatefulSetPod) Run(ctx context.Context, fn func(ordinals []int)) error {
+	selector, err := ss.selector(ctx)
+	if err != nil {
+		return err
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(ss.cs, ss.resync,
+		informers.WithNamespace(ss.namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.LabelSelector = selector.String()
+		}),
+	)
+	pods := factory.Core().V1().Pods()
+	informer := pods.Informer()
+
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	if _, err = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(any) { notify() },
+		UpdateFunc: func(any, any) { notify() },
+		DeleteFunc: func(any) { notify() },
+	}); err != nil {
+		return err
+	}
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return ctx.Err()
+	}
+
+	var (
+		last  []int
+		timer = time.NewTimer(0)
+	)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+			// 去抖：滚动更新等连续变化只通知一次
+			timer.Reset(ss.debounce)
+		case <-timer.C:
+			ordinals, err := ss.ordinals(pods.Lister(), selector)
+			if err != nil {
+				ss.lg.Error(ctx, "list pods", "err", err)
+				continue
+			}
+			if last != nil && slices.Equal(last, ordinals) {
+				continue
+			}
+			ss.lg.Info(ctx, "statefulset ordinals", "sts", ss.stsName, "ordinals", ordinals)
+			last = ordinals
+			fn(ordinals)
+		}
+	}
+}
+
+// selector StatefulSet 的 Pod 标签选择器，获取失败时重试直到 ctx 结束
+func (ss *StatefulSetPod) selector(ctx context.Context) (labels.Selector, error) {
+	for {
+		sts, err := ss.cs.AppsV1().StatefulSets(ss.namespace).Get(ctx, ss.stsName, metav1.GetOptions{})
+		if err == nil {
+			return metav1.LabelSelectorAsSelector(sts.Spec.Selector)
+		}
+		ss.lg.Error(ctx, "get statefulset", "sts", ss.stsName, "err", err)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("get statefulset %s: %w", ss.stsName, err)
+		case <-time.After(ss.retry):
+		}
+	}
+}
+
+// ordinals 缓存中 Ready 的 Pod 序号（升序）
+func (ss *StatefulSetPod) ordinals(lister listersv1.PodLister, selector labels.Selector) ([]int, error) {
+	list, err := lister.Pods(ss.namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]int, 0, len(list))
+	for _, p := range list {
+		n, ok := ss.ordinal(p)
+		if ok && isReady(p) {
+			ret = append(ret, n)
+		}
+	}
+	sort.Ints(ret)
+	return ret, nil
+}
+
+// ordinal 解析 <sts>-<序号> 形式的 Pod 名称
+func (ss *StatefulSetPod) ordinal(p *v1.Pod) (int, bool) {
+	s, ok := strings.CutPrefix(p.Name, ss.stsName+"-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n >= 0
+}
+
+// isReady Pod 未在删除且 Ready 条件为 True
+func isReady(p *v1.Pod) bool {
+	if p.DeletionTimestamp != nil {
+		return false
+	}
+	for _, c := range p.Status.Conditions {
+		if c.Type == v1.PodReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/x-thooh/delay/pkg/log"
+	"github.com/x-thooh/delay/pkg/log/xslog"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newPod(name string, ready bool) *v1.Pod {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "delay"}},
+		Status:     v1.PodStatus{Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: status}}},
+	}
+}
+
+func TestStatefulSetPod(t *testing.T) {
+	lg, _, _ := xslog.New(&log.Config{Model: "std", Level: "error", Format: "text"})
+	other := newPod("other-0", true)
+	other.Labels = map[string]string{"app": "other"}
+	cs := fake.NewClientset(
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "delay", Namespace: "default"},
+			Spec: appsv1.StatefulSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "delay"}},
+			},
+		},
+		newPod("delay-0", true),
+		newPod("delay-1", false),
+		other,
+	)
+
+	ch := make(chan []int, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ss := NewStatefulSetPod(cs, lg, "default", "delay", WithDebounce(200*time.Millisecond))
+	go func() {
+		_ = ss.Run(ctx, func(ordinals []int) { ch <- ordinals })
+	}()
+	expect := func(want ...int) {
+		t.Helper()
+		select {
+		case got := <-ch:
+			if !slices.Equal(got, want) {
+				t.Fatalf("ordinals = %v, want %v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for %v", want)
+		}
+	}
+	pods := cs.CoreV1().Pods("default")
+
+	// 仅统计 Ready 的 Pod
+	expect(0)
+	if _, err := pods.UpdateStatus(ctx, newPod("delay-1", true), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expect(0, 1)
+
+	// 连续变化去抖后只通知一次
+	for _, name := range []string{"delay-2", "delay-3"} {
+		if _, err := pods.Create(ctx, newPod(name, true), metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pods.Delete(ctx, "delay-0", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expect(1, 2, 3)
+
+	// 未影响 Ready 序号的变化不通知
+	if _, err := pods.Update(ctx, newPod("delay-1", true), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-ch:
+		t.Fatalf("unexpected notify %v", got)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	// 删除中的 Pod 不再计入
+	terminating := newPod("delay-3", true)
+	terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	if _, err := pods.Update(ctx, terminating, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expect(1, 2)
+}
@@ -0,0 +1,42 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFS(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(&Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Put(ctx, "ab/1.json", []byte(`{"a":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.Get(ctx, "ab/1.json")
+	if err != nil || string(b) != `{"a":1}` {
+		t.Fatalf("unexpected blob %q %v", b, err)
+	}
+	if err = s.Delete(ctx, "ab/1.json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Get(ctx, "ab/1.json"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err = s.Delete(ctx, "ab/1.json"); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", "../x", "/etc/passwd"} {
+		if err = s.Put(ctx, key, nil); err == nil {
+			t.Fatalf("expected invalid key %q", key)
+		}
+	}
+}
+
+func TestNewDisabled(t *testing.T) {
+	if s, err := New(nil); s != nil || err != nil {
+		t.Fatalf("unexpected store %v %v", s, err)
+	}
+}
@@ -0,0 +1,176 @@
+package breaker
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+type Config struct {
+	Enable bool `yaml:"enable"`
+	// 连续失败多少次后熔断
+	FailureThreshold int `yaml:"failure_threshold"`
+	// 熔断持续时间，到期后进入半开状态
+	OpenTimeout time.Duration `yaml:"open_timeout"`
+	// 半开状态允许同时探测的请求数
+	HalfOpenProbes int `yaml:"half_open_probes"`
+}
+
+type endpoint struct {
+	state    State
+	failures int
+	probes   int
+	retryAt  time.Time
+}
+
+type Stat struct {
+	Endpoint string     `json:"endpoint"`
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	RetryAt  *time.Time `json:"retry_at,omitempty"`
+}
+
+// Breaker 按回调端点划分的熔断器
+type Breaker struct {
+	cfg *Config
+
+	mu        sync.Mutex
+	endpoints map[string]*endpoint
+}
+
+func New(cfg *Config) *Breaker {
+	if cfg == nil || !cfg.Enable {
+		return nil
+	}
+	// 复制一份，默认值不写回调用方的配置
+	c := *cfg
+	cfg = &c
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	return &Breaker{
+		cfg:       cfg,
+		endpoints: make(map[string]*endpoint),
+	}
+}
+
+// Allow 判断端点是否允许请求，不允许时返回下一次可探测的时间
+func (b *Breaker) Allow(key string) (time.Time, bool) {
+	if b == nil {
+		return time.Time{}, true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.endpoints[key]
+	if !ok {
+		return time.Time{}, true
+	}
+	now := time.Now()
+	switch e.state {
+	case StateOpen:
+		if now.Before(e.retryAt) {
+			return e.retryAt, false
+		}
+		e.state = StateHalfOpen
+		e.probes = 0
+		fallthrough
+	case StateHalfOpen:
+		if e.probes >= b.cfg.HalfOpenProbes {
+			// 探测结果未返回，等待下一个探测窗口
+			return now.Add(b.cfg.OpenTimeout), false
+		}
+		e.probes++
+	}
+	return time.Time{}, true
+}
+
+// Release 归还 Allow 占用的探测名额，用于放行后未实际发出请求的情况
+func (b *Breaker) Release(key string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if e, ok := b.endpoints[key]; ok && e.state == StateHalfOpen && e.probes > 0 {
+		e.probes--
+	}
+}
+
+// Report 上报请求结果
+func (b *Breaker) Report(key string, success bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.endpoints[key]
+	if !ok {
+		if success {
+			return
+		}
+		e = &endpoint{}
+		b.endpoints[key] = e
+	}
+	if success {
+		delete(b.endpoints, key)
+		return
+	}
+	e.failures++
+	if e.state == StateHalfOpen || e.failures >= b.cfg.FailureThreshold {
+		e.state = StateOpen
+		e.probes = 0
+		e.retryAt = time.Now().Add(b.cfg.OpenTimeout)
+	}
+}
+
+// Stats 返回非关闭状态或存在失败记录的端点
+func (b *Breaker) Stats() []*Stat {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ret := make([]*Stat, 0, len(b.endpoints))
+	for k, e := range b.endpoints {
+		st := &Stat{
+			Endpoint: k,
+			State:    e.state.String(),
+			Failures: e.failures,
+		}
+		if e.state != StateClosed {
+			retryAt := e.retryAt
+			st.RetryAt = &retryAt
+		}
+		ret = append(ret, st)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Endpoint < ret[j].Endpoint
+	})
+	return ret
+}
@@ -0,0 +1,88 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	b := New(&Config{
+		Enable:           true,
+		FailureThreshold: 2,
+		OpenTimeout:      50 * time.Millisecond,
+		HalfOpenProbes:   1,
+	})
+	key := "127.0.0.1:8081/example/valid"
+
+	for i := 0; i < 2; i++ {
+		if _, ok := b.Allow(key); !ok {
+			t.Fatalf("request %d should be allowed", i)
+		}
+		b.Report(key, false)
+	}
+	retryAt, ok := b.Allow(key)
+	if ok {
+		t.Fatal("breaker should be open")
+	}
+	if retryAt.IsZero() {
+		t.Fatal("retry time should be set")
+	}
+
+	time.Sleep(time.Until(retryAt) + 10*time.Millisecond)
+	if _, ok = b.Allow(key); !ok {
+		t.Fatal("half-open probe should be allowed")
+	}
+	if _, ok = b.Allow(key); ok {
+		t.Fatal("only one probe should be allowed")
+	}
+	if st := b.Stats(); len(st) != 1 || st[0].State != StateHalfOpen.String() {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+
+	b.Report(key, true)
+	if _, ok = b.Allow(key); !ok {
+		t.Fatal("breaker should be closed after successful probe")
+	}
+	if st := b.Stats(); len(st) != 0 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	b := New(&Config{Enable: true, FailureThreshold: 1, OpenTimeout: time.Millisecond, HalfOpenProbes: 1})
+	key := "127.0.0.1:8081/example"
+	b.Report(key, false)
+	time.Sleep(2 * time.Millisecond)
+
+	if _, ok := b.Allow(key); !ok {
+		t.Fatal("expected half-open probe")
+	}
+	if _, ok := b.Allow(key); ok {
+		t.Fatal("probe slot should be taken")
+	}
+	// 未发出请求时归还探测名额
+	b.Release(key)
+	if _, ok := b.Allow(key); !ok {
+		t.Fatal("expected probe after release")
+	}
+}
+
+func TestStats(t *testing.T) {
+	cfg := &Config{Enable: true}
+	b := New(cfg)
+	if cfg.FailureThreshold != 0 || cfg.OpenTimeout != 0 {
+		t.Fatalf("config modified %+v", cfg)
+	}
+	// 未熔断的端点不返回 retry_at
+	b.Report("a", false)
+	st := b.Stats()
+	if len(st) != 1 || st[0].RetryAt != nil {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+	for i := 0; i < 4; i++ {
+		b.Report("a", false)
+	}
+	if st = b.Stats(); st[0].State != StateOpen.String() || st[0].RetryAt == nil {
+		t.Fatalf("unexpected stats %+v", st[0])
+	}
+}
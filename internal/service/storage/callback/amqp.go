@@ -0,0 +1,233 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/x-thooh/delay/pkg/log"
+)
+
+var (
+	ErrNack       = errors.New("amqp: message nacked by broker")
+	ErrUnroutable = errors.New("amqp: message returned as unroutable")
+)
+
+// AmqpConfig AMQP 回调配置，TLS 按地址从 targets 中查找，amqps:// 地址默认启用
+type AmqpConfig struct {
+	// 每个连接缓存的 confirm 模式 channel 数，默认 8
+	Channels int `yaml:"channels"`
+	// 消息无法路由到队列时视为失败
+	Mandatory   bool          `yaml:"mandatory"`
+	Heartbeat   time.Duration `yaml:"heartbeat"`
+	DialTimeout time.Duration `yaml:"dial_timeout"`
+}
+
+// Amqp 将回调数据发布到 exchange：url 为 amqp 地址，path 为 exchange（"/" 为默认 exchange），
+// key 为 routing key，broker 确认即回调成功
+type Amqp struct {
+	mu    sync.Mutex
+	conns map[string]*amqpConn
+	lg    log.Logger
+	cfg   *Config
+}
+
+// amqpConn 连接及其 channel 池
+type amqpConn struct {
+	conn     *amqp.Connection
+	channels chan *amqpChannel
+}
+
+type amqpChannel struct {
+	ch      *amqp.Channel
+	returns chan amqp.Return
+}
+
+func NewAmqp(lg log.Logger, cfg *Config) ICallback {
+	return &Amqp{
+		conns: make(map[string]*amqpConn),
+		lg:    lg,
+		cfg:   cfg,
+	}
+}
+
+func (a *Amqp) config() *AmqpConfig {
+	c := AmqpConfig{Channels: 8, DialTimeout: 5 * time.Second}
+	if a.cfg == nil || a.cfg.Amqp == nil {
+		return &c
+	}
+	if a.cfg.Amqp.Channels > 0 {
+		c.Channels = a.cfg.Amqp.Channels
+	}
+	if a.cfg.Amqp.DialTimeout > 0 {
+		c.DialTimeout = a.cfg.Amqp.DialTimeout
+	}
+	c.Mandatory = a.cfg.Amqp.Mandatory
+	c.Heartbeat = a.cfg.Amqp.Heartbeat
+	return &c
+}
+
+// getConn 获取连接，已断开的连接重新建立
+func (a *Amqp) getConn(url string) (*amqpConn, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if c, ok := a.conns[url]; ok {
+		if !c.conn.IsClosed() {
+			return c, nil
+		}
+		delete(a.conns, url)
+		c.drain()
+	}
+
+	ac := a.config()
+	dc := amqp.Config{
+		Heartbeat: ac.Heartbeat,
+		Dial:      amqp.DefaultDial(ac.DialTimeout),
+	}
+	if t := a.cfg.Target(Host(url)); t.TLS.Enabled() {
+		tc, err := t.TLS.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		dc.TLSClientConfig = tc
+	}
+	conn, err := amqp.DialConfig(url, dc)
+	if err != nil {
+		return nil, err
+	}
+	c := &amqpConn{conn: conn, channels: make(chan *amqpChannel, ac.Channels)}
+	a.conns[url] = c
+	return c, nil
+}
+
+// acquire 从池中取出 channel，池为空时新建
+func (c *amqpConn) acquire() (*amqpChannel, error) {
+	for {
+		select {
+		case ch := <-c.channels:
+			if !ch.ch.IsClosed() {
+				return ch, nil
+			}
+		default:
+			ch, err := c.conn.Channel()
+			if err != nil {
+				return nil, err
+			}
+			if err = ch.Confirm(false); err != nil {
+				_ = ch.Close()
+				return nil, err
+			}
+			return &amqpChannel{ch: ch, returns: ch.NotifyReturn(make(chan amqp.Return, 1))}, nil
+		}
+	}
+}
+
+// release 归还 channel，池已满或 channel 异常时关闭
+func (c *amqpConn) release(ch *amqpChannel, ok bool) {
+	if ok && !ch.ch.IsClosed() {
+		select {
+		case c.channels <- ch:
+			return
+		default:
+		}
+	}
+	_ = ch.ch.Close()
+}
+
+func (c *amqpConn) drain() {
+	for {
+		select {
+		case ch := <-c.channels:
+			_ = ch.ch.Close()
+		default:
+			return
+		}
+	}
+}
+
+func (c *amqpConn) close() error {
+	c.drain()
+	return c.conn.Close()
+}
+
+func (a *Amqp) Request(ctx context.Context, payload *Payload) (string, error) {
+	c, err := a.getConn(payload.Url)
+	if err != nil {
+		return "", err
+	}
+	contentType, body, err := payload.Encode(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ch, err := c.acquire()
+	if err != nil {
+		return "", err
+	}
+	ok := false
+	defer func() {
+		c.release(ch, ok)
+	}()
+
+	exchange := strings.TrimPrefix(payload.Path, "/")
+	mandatory := a.config().Mandatory
+	meta := FromContext(ctx)
+	dc, err := ch.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, payload.Key, mandatory, false, amqp.Publishing{
+		Headers:      amqpHeaders(ctx, payload),
+		ContentType:  contentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    strconv.FormatInt(meta.TaskNo, 10),
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+	if err != nil {
+		return "", err
+	}
+	acked, err := dc.WaitContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !acked {
+		return "", ErrNack
+	}
+	// 不可路由的消息在确认前返回
+	select {
+	case <-ch.returns:
+		ok = true
+		return "", ErrUnroutable
+	default:
+	}
+	ok = true
+	return "SUCCESS", nil
+}
+
+// amqpHeaders 消息头：任务请求头、链路及任务信息
+func amqpHeaders(ctx context.Context, payload *Payload) amqp.Table {
+	headers := amqp.Table{}
+	for k, v := range payload.Headers {
+		headers[k] = v
+	}
+	for k, v := range MetaHeaders(ctx) {
+		headers[k] = v
+	}
+	return headers
+}
+
+func (a *Amqp) Close(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var errs []error
+	for url, c := range a.conns {
+		delete(a.conns, url)
+		// 地址可能含密码，不写入错误
+		if err := c.close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			errs = append(errs, fmt.Errorf("close amqp connection: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,241 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/x-thooh/delay/pkg/trace"
+)
+
+// fakeAmqp 仅支持发布及 publisher confirm 的 AMQP 0-9-1 服务端
+type fakeAmqp struct {
+	lis net.Listener
+
+	mu        sync.Mutex
+	published []*published
+	conns     int
+}
+
+type published struct {
+	exchange, key string
+	header        []byte
+	body          []byte
+}
+
+func startAmqp(t *testing.T) *fakeAmqp {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeAmqp{lis: lis}
+	go func() {
+		for {
+			c, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+			go s.serve(c)
+		}
+	}()
+	t.Cleanup(func() {
+		_ = lis.Close()
+	})
+	return s
+}
+
+func (s *fakeAmqp) url() string {
+	return "amqp://guest:guest@" + s.lis.Addr().String() + "/"
+}
+
+func (s *fakeAmqp) messages() []*published {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*published(nil), s.published...)
+}
+
+func (s *fakeAmqp) serve(c net.Conn) {
+	defer c.Close()
+	if _, err := io.ReadFull(c, make([]byte, 8)); err != nil {
+		return
+	}
+	w := &amqpWriter{c: c}
+	var table [4]byte
+	w.method(0, 10, 10, []byte{0, 9}, table[:], longstr("PLAIN"), longstr("en_US"))
+
+	tags := make(map[uint16]uint64)
+	var pub *published
+	for {
+		typ, channel, payload, err := readFrame(c)
+		if err != nil {
+			return
+		}
+		switch typ {
+		case 1:
+			class, method := binary.BigEndian.Uint16(payload), binary.BigEndian.Uint16(payload[2:])
+			args := payload[4:]
+			switch {
+			case class == 10 && method == 11: // start-ok
+				w.method(0, 10, 30, u16(0), u32(131072), u16(0))
+			case class == 10 && method == 40: // open
+				w.method(0, 10, 41, shortstr(""))
+			case class == 10 && method == 50: // close
+				w.method(0, 10, 51)
+				return
+			case class == 20 && method == 10: // channel.open
+				w.method(channel, 20, 11, longstr(""))
+			case class == 20 && method == 40: // channel.close
+				w.method(channel, 20, 41)
+			case class == 85 && method == 10: // confirm.select
+				w.method(channel, 85, 11)
+			case class == 60 && method == 40: // basic.publish
+				exchange, rest := readShortstr(args[2:])
+				key, rest := readShortstr(rest)
+				pub = &published{exchange: exchange, key: key}
+				if exchange == "missing" && rest[0]&1 == 1 {
+					pub.exchange = "!" + exchange
+				}
+			}
+		case 2:
+			pub.header = payload
+			if binary.BigEndian.Uint64(payload[4:]) > 0 {
+				continue
+			}
+			fallthrough
+		case 3:
+			pub.body = append(pub.body, payload...)
+			tags[channel]++
+			tag := u64(tags[channel])
+			switch pub.exchange {
+			case "!missing": // mandatory 且不可路由
+				w.method(channel, 60, 50, u16(312), shortstr("NO_ROUTE"), shortstr("missing"), shortstr(pub.key))
+				w.frame(2, channel, pub.header)
+				w.frame(3, channel, pub.body)
+				w.method(channel, 60, 80, tag, []byte{0})
+			case "nack":
+				w.method(channel, 60, 120, tag, []byte{0})
+			default:
+				s.mu.Lock()
+				s.published = append(s.published, pub)
+				s.mu.Unlock()
+				w.method(channel, 60, 80, tag, []byte{0})
+			}
+		}
+	}
+}
+
+type amqpWriter struct {
+	mu sync.Mutex
+	c  net.Conn
+}
+
+func (w *amqpWriter) frame(typ byte, channel uint16, payload []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	b := []byte{typ}
+	b = binary.BigEndian.AppendUint16(b, channel)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(payload)))
+	b = append(append(b, payload...), 0xce)
+	_, _ = w.c.Write(b)
+}
+
+func (w *amqpWriter) method(channel, class, method uint16, args ...[]byte) {
+	b := binary.BigEndian.AppendUint16(nil, class)
+	b = binary.BigEndian.AppendUint16(b, method)
+	for _, a := range args {
+		b = append(b, a...)
+	}
+	w.frame(1, channel, b)
+}
+
+func readFrame(r io.Reader) (byte, uint16, []byte, error) {
+	var h [7]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return 0, 0, nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(h[3:])+1)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, 0, nil, err
+	}
+	if payload[len(payload)-1] != 0xce {
+		return 0, 0, nil, errors.New("bad frame end")
+	}
+	return h[0], binary.BigEndian.Uint16(h[1:]), payload[:len(payload)-1], nil
+}
+
+func u16(v uint16) []byte { return binary.BigEndian.AppendUint16(nil, v) }
+func u32(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
+func u64(v uint64) []byte { return binary.BigEndian.AppendUint64(nil, v) }
+
+func shortstr(s string) []byte { return append([]byte{byte(len(s))}, s...) }
+func longstr(s string) []byte  { return append(u32(uint32(len(s))), s...) }
+
+func readShortstr(b []byte) (string, []byte) {
+	n := int(b[0])
+	return string(b[1 : 1+n]), b[1+n:]
+}
+
+func TestAmqpRequest(t *testing.T) {
+	s := startAmqp(t)
+	a := NewAmqp(setLogger(), &Config{Amqp: &AmqpConfig{Channels: 2, Mandatory: true}})
+	defer func() {
+		_ = a.Close(context.Background())
+	}()
+
+	ctx := NewContext(trace.Set(context.Background(), "t-1"), &Meta{TaskNo: 42, Tenant: "order"})
+	for i := 0; i < 3; i++ {
+		ret, err := a.Request(ctx, &Payload{
+			Schema:  "AMQP",
+			Url:     s.url(),
+			Path:    "orders",
+			Key:     "order.paid",
+			Data:    map[string]any{"order_id": "A001"},
+			Headers: map[string]string{"x-custom": "1"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ret != "SUCCESS" {
+			t.Fatalf("unexpected result %q", ret)
+		}
+	}
+
+	msgs := s.messages()
+	if len(msgs) != 3 {
+		t.Fatalf("unexpected messages %d", len(msgs))
+	}
+	m := msgs[0]
+	if m.exchange != "orders" || m.key != "order.paid" || string(m.body) != `{"order_id":"A001"}` {
+		t.Fatalf("unexpected message %s %s %s", m.exchange, m.key, m.body)
+	}
+	for _, want := range []string{"application/json", "x-custom", "X-Trace-ID", "t-1", "X-Delay-Task-No", "X-Tenant-Id", "order"} {
+		if !bytes.Contains(m.header, []byte(want)) {
+			t.Fatalf("header missing %q", want)
+		}
+	}
+	// 连接及 channel 复用
+	s.mu.Lock()
+	conns := s.conns
+	s.mu.Unlock()
+	if conns != 1 {
+		t.Fatalf("unexpected connections %d", conns)
+	}
+
+	if _, err := a.Request(ctx, &Payload{Url: s.url(), Path: "nack"}); !errors.Is(err, ErrNack) {
+		t.Fatalf("expected nack, got %v", err)
+	}
+	if _, err := a.Request(ctx, &Payload{Url: s.url(), Path: "missing"}); !errors.Is(err, ErrUnroutable) {
+		t.Fatalf("expected unroutable, got %v", err)
+	}
+	if _, err := a.Request(ctx, &Payload{Url: s.url(), Path: "orders"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,151 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/x-thooh/delay/pkg/trace"
+)
+
+const (
+	ContentTypeJSON = "json"
+	ContentTypeForm = "form"
+	ContentTypeRaw  = "raw"
+)
+
+// templateFuncs json 将值编码为 JSON，json 内容类型的模板应使用它输出值
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// ParseTemplate 解析请求体模板
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("body").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// TemplateData 请求体模板可用的任务字段
+type TemplateData struct {
+	TaskNo      int64
+	Tenant      string
+	TraceId     string
+	Attempt     int
+	ScheduledAt time.Time
+	FiredAt     time.Time
+	Data        map[string]any
+}
+
+// HttpMethod 返回请求方法，默认 POST
+func (p *Payload) HttpMethod() string {
+	if p.Method == "" {
+		return http.MethodPost
+	}
+	return strings.ToUpper(p.Method)
+}
+
+// Encode 按内容类型及模板生成请求体
+func (p *Payload) Encode(ctx context.Context) (string, []byte, error) {
+	var (
+		body []byte
+		err  error
+	)
+	if p.Template != "" {
+		if body, err = p.render(ctx); err != nil {
+			return "", nil, err
+		}
+	}
+	switch strings.ToLower(p.ContentType) {
+	case "", ContentTypeJSON:
+		if body == nil {
+			if body, err = p.Message(ctx); err != nil {
+				return "", nil, err
+			}
+		} else if !json.Valid(body) {
+			// 模板不做转义，字符串值需使用 json 函数输出
+			return "", nil, errors.New("template output is not valid json, quote values with {{json .Data.key}}")
+		}
+		return "application/json", body, nil
+	case ContentTypeForm:
+		if body == nil {
+			body = []byte(formValues(p.Data).Encode())
+		}
+		return "application/x-www-form-urlencoded", body, nil
+	case ContentTypeRaw:
+		return "text/plain; charset=utf-8", body, nil
+	}
+	return "", nil, fmt.Errorf("unsupported content type %q", p.ContentType)
+}
+
+func (p *Payload) render(ctx context.Context) ([]byte, error) {
+	tpl, err := ParseTemplate(p.Template)
+	if err != nil {
+		return nil, err
+	}
+	meta := FromContext(ctx)
+	buf := &bytes.Buffer{}
+	if err = tpl.Execute(buf, &TemplateData{
+		TaskNo:      meta.TaskNo,
+		Tenant:      meta.Tenant,
+		TraceId:     trace.Get(ctx),
+		Attempt:     meta.Attempt,
+		ScheduledAt: meta.ScheduledAt,
+		FiredAt:     meta.FiredAt,
+		Data:        p.Data,
+	}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RequestUrl 拼接回调地址及查询参数
+func (p *Payload) RequestUrl() (string, error) {
+	raw := p.Url + p.Path
+	if !strings.HasPrefix(raw, "http") {
+		raw = fmt.Sprintf("%s://%s", strings.ToLower(p.Schema), raw)
+	}
+	if len(p.Query) == 0 {
+		return raw, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for k, v := range p.Query {
+		q.Set(k, v)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func formValues(data map[string]any) url.Values {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	vs := url.Values{}
+	for _, k := range keys {
+		switch v := data[k].(type) {
+		case string:
+			vs.Set(k, v)
+		case map[string]any, []any:
+			b, _ := json.Marshal(v)
+			vs.Set(k, string(b))
+		default:
+			vs.Set(k, fmt.Sprint(v))
+		}
+	}
+	return vs
+}
@@ -5,8 +5,11 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
-	"github.com/x-thooh/delay/pkg/log"
+	"github.com/x-thooh/delay/pkg/keyring"
+	"github.com/x-thooh/delay/pkg/tlsx"
 )
 
 type Payload struct {
@@ -14,6 +17,29 @@ type Payload struct {
 	Url    string         `json:"url,omitempty"`
 	Path   string         `json:"path,omitempty"`
 	Data   map[string]any `json:"data,omitempty"`
+	// data 存入外部存储时的引用，执行前读取
+	DataRef string `json:"data_ref,omitempty"`
+	// 回调签名密钥，优先于租户密钥
+	Secret string `json:"secret,omitempty"`
+
+	// HTTP 回调：请求方法、请求头、查询参数、内容类型（json、form、raw）及请求体模板
+	Method      string            `json:"method,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Query       map[string]string `json:"query,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Template    string            `json:"template,omitempty"`
+
+	// 消息回调：KAFKA 消息键（默认任务编号），AMQP routing key
+	Key string `json:"key,omitempty"`
+
+	// 回调数据格式：raw（默认）、envelope
+	Mode string `json:"mode,omitempty"`
+
+	// 回调成功后转发响应的回调
+	OnComplete *Payload `json:"on_complete,omitempty"`
+
+	// 加密存储时的密文，其余字段均为空，执行前解密
+	Sealed *keyring.Sealed `json:"sealed,omitempty"`
 }
 
 func (p Payload) Value() (driver.Value, error) {
@@ -28,21 +54,169 @@ func (p *Payload) Scan(src interface{}) error {
 	return json.Unmarshal(b, p)
 }
 
-type ICallback interface {
-	SetLogger(lg log.Logger) ICallback
-	Request(ctx context.Context, payload *Payload) (string, error)
-	Close(ctx context.Context) error
+type Config struct {
+	// 具名回调实例，任务 schema 按名称选择实例
+	Adapters []*Adapter `yaml:"adapters"`
+	// 按回调目标 host 配置，host 为 * 时作为默认配置
+	Targets []*Target `yaml:"targets"`
+	// 回调签名
+	Signing *Signing `yaml:"signing"`
+	// HTTP 回调连接池默认配置
+	Http *HttpConfig `yaml:"http"`
+	// GRPC 回调方法描述
+	Grpc *GrpcConfig `yaml:"grpc"`
+	// KAFKA 回调生产者配置
+	Kafka *KafkaConfig `yaml:"kafka"`
+	// REDIS 回调客户端配置
+	Redis *RedisConfig `yaml:"redis"`
+	// AMQP 回调配置
+	Amqp *AmqpConfig `yaml:"amqp"`
+	// EXEC 回调命令白名单
+	Exec *ExecConfig `yaml:"exec"`
 }
 
-var adapter = make(map[string]ICallback)
+// HttpConfig HTTP 回调连接池，每个目标 host 独立；请求整体超时由任务 timeout 决定
+type HttpConfig struct {
+	ConnectTimeout        time.Duration `yaml:"connect_timeout"`
+	TLSHandshakeTimeout   time.Duration `yaml:"tls_handshake_timeout"`
+	ResponseHeaderTimeout time.Duration `yaml:"response_header_timeout"`
+	IdleConnTimeout       time.Duration `yaml:"idle_conn_timeout"`
+	MaxIdleConnsPerHost   int           `yaml:"max_idle_conns_per_host"`
+	MaxConnsPerHost       int           `yaml:"max_conns_per_host"`
+	// 成功判定：body（默认）响应体为 SUCCESS，status 状态码为 2xx
+	Success string `yaml:"success"`
+}
 
-func GetAdapter(lg log.Logger) map[string]ICallback {
-	for _, c := range adapter {
-		c.SetLogger(lg)
+// merge 以 o 中非零值覆盖默认配置
+func (h HttpConfig) merge(o *HttpConfig) *HttpConfig {
+	if o == nil {
+		return &h
+	}
+	if o.ConnectTimeout > 0 {
+		h.ConnectTimeout = o.ConnectTimeout
+	}
+	if o.TLSHandshakeTimeout > 0 {
+		h.TLSHandshakeTimeout = o.TLSHandshakeTimeout
+	}
+	if o.ResponseHeaderTimeout > 0 {
+		h.ResponseHeaderTimeout = o.ResponseHeaderTimeout
+	}
+	if o.IdleConnTimeout > 0 {
+		h.IdleConnTimeout = o.IdleConnTimeout
 	}
-	return adapter
+	if o.MaxIdleConnsPerHost > 0 {
+		h.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	}
+	if o.MaxConnsPerHost > 0 {
+		h.MaxConnsPerHost = o.MaxConnsPerHost
+	}
+	if o.Success != "" {
+		h.Success = o.Success
+	}
+	return &h
+}
+
+// HttpConfig 返回目标的 HTTP 连接池配置：内置默认 < 全局默认 < 目标配置
+func (c *Config) HttpConfig(t *Target) *HttpConfig {
+	def := HttpConfig{
+		ConnectTimeout:      3 * time.Second,
+		TLSHandshakeTimeout: 3 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+		MaxIdleConnsPerHost: 100,
+		MaxConnsPerHost:     1000,
+	}
+	if c != nil {
+		def = *def.merge(c.Http)
+	}
+	return def.merge(t.Http)
+}
+
+type Signing struct {
+	// 默认密钥，为空时不签名
+	Secret string `yaml:"secret"`
+	// 租户密钥
+	Tenants map[string]string `yaml:"tenants"`
 }
 
-func RegisterAdapter(name string, c ICallback) {
-	adapter[name] = c
+// Secret 返回签名密钥：任务密钥 > 租户密钥 > 默认密钥
+func (c *Config) Secret(payload *Payload, tenant string) string {
+	if payload.Secret != "" {
+		return payload.Secret
+	}
+	if c == nil || c.Signing == nil {
+		return ""
+	}
+	if s, ok := c.Signing.Tenants[tenant]; ok {
+		return s
+	}
+	return c.Signing.Secret
+}
+
+type Target struct {
+	Host string       `yaml:"host"`
+	TLS  *tlsx.Config `yaml:"tls"`
+	Http *HttpConfig  `yaml:"http"`
+	Grpc *GrpcDial    `yaml:"grpc"`
+}
+
+// Target 返回回调目标的配置，未配置时返回默认配置
+func (c *Config) Target(host string) *Target {
+	if c == nil {
+		return &Target{Host: host}
+	}
+	var def *Target
+	for _, t := range c.Targets {
+		if strings.EqualFold(t.Host, host) {
+			return t
+		}
+		if t.Host == "*" {
+			def = t
+		}
+	}
+	if def != nil {
+		return def
+	}
+	return &Target{Host: host}
+}
+
+// Meta 回调任务信息
+type Meta struct {
+	TaskNo int64
+	Tenant string
+	// 第几次执行，从 1 开始
+	Attempt int
+	// 计划执行时间及实际执行时间
+	ScheduledAt time.Time
+	FiredAt     time.Time
+	// 适配器按自身规则（HTTP 2xx、gRPC 结果字段）判定成功时置为 true，响应原样作为任务结果
+	Succeeded bool
+}
+
+type metaCtxKey struct{}
+
+func NewContext(ctx context.Context, m *Meta) context.Context {
+	return context.WithValue(ctx, metaCtxKey{}, m)
+}
+
+func FromContext(ctx context.Context) *Meta {
+	if m, ok := ctx.Value(metaCtxKey{}).(*Meta); ok {
+		return m
+	}
+	return &Meta{}
+}
+
+type ICallback interface {
+	Request(ctx context.Context, payload *Payload) (string, error)
+	Close(ctx context.Context) error
+}
+
+// Host 提取回调地址中的主机部分，兼容无协议前缀的地址
+func Host(url string) string {
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	}
+	if i := strings.IndexAny(url, "/?#"); i >= 0 {
+		url = url[:i]
+	}
+	return strings.ToLower(url)
 }
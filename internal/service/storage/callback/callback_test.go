@@ -0,0 +1,32 @@
+package callback
+
+import "testing"
+
+func TestHost(t *testing.T) {
+	cases := map[string]string{
+		"127.0.0.1:8081":              "127.0.0.1:8081",
+		"http://Example.com/a/b?c=1":  "example.com",
+		"https://example.com:8443/ok": "example.com:8443",
+	}
+	for in, want := range cases {
+		if got := Host(in); got != want {
+			t.Fatalf("Host(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestConfigTarget(t *testing.T) {
+	cfg := &Config{Targets: []*Target{
+		{Host: "*"},
+		{Host: "pay.example.com"},
+	}}
+	if got := cfg.Target("PAY.example.com").Host; got != "pay.example.com" {
+		t.Fatalf("unexpected target %q", got)
+	}
+	if got := cfg.Target("other").Host; got != "*" {
+		t.Fatalf("unexpected default target %q", got)
+	}
+	if got := (*Config)(nil).Target("other").Host; got != "other" {
+		t.Fatalf("unexpected nil config target %q", got)
+	}
+}
@@ -0,0 +1,96 @@
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/x-thooh/delay/pkg/signature"
+	"github.com/x-thooh/delay/pkg/tenant"
+	"github.com/x-thooh/delay/pkg/trace"
+)
+
+// 回调数据格式
+const (
+	// ModeRaw 请求体为任务 data，任务信息通过请求头（消息头）传递，默认
+	ModeRaw = "raw"
+	// ModeEnvelope 请求体为包含任务信息及 data 的信封
+	ModeEnvelope = "envelope"
+
+	// EnvelopeVersion 信封格式版本，字段只增不改
+	EnvelopeVersion = 1
+)
+
+const (
+	HeaderAttempt     = "X-Delay-Attempt"
+	HeaderScheduledAt = "X-Delay-Scheduled-At"
+	HeaderFiredAt     = "X-Delay-Fired-At"
+)
+
+// Envelope 回调信封
+type Envelope struct {
+	Version     int            `json:"version"`
+	TaskNo      int64          `json:"task_no,string"`
+	Attempt     int            `json:"attempt"`
+	ScheduledAt time.Time      `json:"scheduled_at"`
+	FiredAt     time.Time      `json:"fired_at"`
+	TraceId     string         `json:"trace_id,omitempty"`
+	Data        map[string]any `json:"data"`
+}
+
+// Message 回调数据的 JSON：raw 模式为 data，envelope 模式为信封
+func (p *Payload) Message(ctx context.Context) ([]byte, error) {
+	if p.Mode != ModeEnvelope {
+		return json.Marshal(p.Data)
+	}
+	return json.Marshal(p.Envelope(ctx))
+}
+
+// Envelope 按任务信息生成信封
+func (p *Payload) Envelope(ctx context.Context) *Envelope {
+	meta := FromContext(ctx)
+	data := p.Data
+	if data == nil {
+		data = map[string]any{}
+	}
+	return &Envelope{
+		Version:     EnvelopeVersion,
+		TaskNo:      meta.TaskNo,
+		Attempt:     meta.Attempt,
+		ScheduledAt: meta.ScheduledAt,
+		FiredAt:     meta.FiredAt,
+		TraceId:     trace.Get(ctx),
+		Data:        data,
+	}
+}
+
+// empty 没有回调数据：raw 模式且 data 为空
+func (p *Payload) empty() bool {
+	return p.Mode != ModeEnvelope && len(p.Data) == 0
+}
+
+// MetaHeaders 任务信息请求头（消息头）：链路、任务编号、租户、执行次数、计划及实际执行时间
+func MetaHeaders(ctx context.Context) map[string]string {
+	h := make(map[string]string, 6)
+	if tid := trace.Get(ctx); tid != "" {
+		h[trace.GetHeaderKey()] = tid
+	}
+	meta := FromContext(ctx)
+	if meta.TaskNo > 0 {
+		h[signature.HeaderTaskNo] = strconv.FormatInt(meta.TaskNo, 10)
+	}
+	if meta.Tenant != "" {
+		h[tenant.GetHeaderKey()] = meta.Tenant
+	}
+	if meta.Attempt > 0 {
+		h[HeaderAttempt] = strconv.Itoa(meta.Attempt)
+	}
+	if !meta.ScheduledAt.IsZero() {
+		h[HeaderScheduledAt] = meta.ScheduledAt.Format(time.RFC3339Nano)
+	}
+	if !meta.FiredAt.IsZero() {
+		h[HeaderFiredAt] = meta.FiredAt.Format(time.RFC3339Nano)
+	}
+	return h
+}
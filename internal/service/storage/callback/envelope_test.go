@@ -0,0 +1,71 @@
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/x-thooh/delay/pkg/trace"
+)
+
+func TestPayloadEnvelope(t *testing.T) {
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctx := NewContext(trace.Set(context.Background(), "t-1"), &Meta{
+		TaskNo:      42,
+		Tenant:      "order",
+		Attempt:     2,
+		ScheduledAt: at,
+		FiredAt:     at.Add(time.Second),
+	})
+	data := map[string]any{"order_id": "A001", "original": "user"}
+
+	_, body, err := (&Payload{Data: data}).Encode(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"order_id":"A001","original":"user"}` {
+		t.Fatalf("unexpected raw body %s", body)
+	}
+
+	_, body, err = (&Payload{Data: data, Mode: ModeEnvelope}).Encode(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env Envelope
+	if err = json.Unmarshal(body, &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.Version != EnvelopeVersion || env.TaskNo != 42 || env.Attempt != 2 || env.TraceId != "t-1" ||
+		!env.ScheduledAt.Equal(at) || !env.FiredAt.Equal(at.Add(time.Second)) || env.Data["order_id"] != "A001" {
+		t.Fatalf("unexpected envelope %s", body)
+	}
+	if len(data) != 2 {
+		t.Fatalf("payload data mutated: %+v", data)
+	}
+
+	// 没有 data 时信封中为空对象
+	b, err := (&Payload{Mode: ModeEnvelope}).Message(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(b), `"data":{}}`) || !strings.Contains(string(b), `"task_no":"42"`) {
+		t.Fatalf("unexpected envelope %s", b)
+	}
+
+	h := MetaHeaders(ctx)
+	want := map[string]string{
+		"X-Trace-ID":      "t-1",
+		"X-Delay-Task-No": "42",
+		"X-Tenant-Id":     "order",
+		HeaderAttempt:     "2",
+		HeaderScheduledAt: "2025-01-02T03:04:05Z",
+		HeaderFiredAt:     "2025-01-02T03:04:06Z",
+	}
+	for k, v := range want {
+		if h[k] != v {
+			t.Fatalf("header %s = %q, want %q", k, h[k], v)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/x-thooh/delay/pkg/log"
+	"github.com/x-thooh/delay/pkg/trace"
+)
+
+var ErrCommandNotAllowed = errors.New("exec: command not in whitelist")
+
+// ExecConfig EXEC 回调配置，只能执行白名单中的命令
+type ExecConfig struct {
+	Commands []*ExecCommand `yaml:"commands"`
+	// stdout、stderr 各自保留的最大字节数，默认 64KB
+	MaxOutput int `yaml:"max_output"`
+}
+
+type ExecCommand struct {
+	// 任务 path 中使用的命令名
+	Name string `yaml:"name"`
+	// 可执行文件路径
+	Path string `yaml:"path"`
+	// 固定参数，任务参数追加在其后
+	Args []string `yaml:"args"`
+	Dir  string   `yaml:"dir"`
+	// 固定环境变量 KEY=VALUE，不继承服务进程的环境变量
+	Env []string `yaml:"env"`
+	// 允许任务传入的环境变量名
+	AllowEnv []string `yaml:"allow_env"`
+}
+
+// ExecResult 命令执行结果，作为回调响应记录
+type ExecResult struct {
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+}
+
+// Exec 执行本地命令：path 为白名单中的命令名，data.args 为参数数组，data.env 为环境变量，
+// 退出码为 0 即回调成功，超时按进程组终止
+type Exec struct {
+	lg  log.Logger
+	cfg *Config
+}
+
+func NewExec(lg log.Logger, cfg *Config) ICallback {
+	return &Exec{lg: lg, cfg: cfg}
+}
+
+func (e *Exec) command(name string) *ExecCommand {
+	if e.cfg == nil || e.cfg.Exec == nil {
+		return nil
+	}
+	for _, c := range e.cfg.Exec.Commands {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (e *Exec) maxOutput() int {
+	if e.cfg == nil || e.cfg.Exec == nil || e.cfg.Exec.MaxOutput <= 0 {
+		return 64 << 10
+	}
+	return e.cfg.Exec.MaxOutput
+}
+
+func (e *Exec) Request(ctx context.Context, payload *Payload) (string, error) {
+	c := e.command(payload.Path)
+	if c == nil {
+		return "", fmt.Errorf("%w: %q", ErrCommandNotAllowed, payload.Path)
+	}
+	args, env, err := execArgs(payload.Data)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, c.Path, append(append([]string(nil), c.Args...), args...)...)
+	cmd.Dir = c.Dir
+	cmd.Env = append([]string(nil), c.Env...)
+	for _, k := range c.AllowEnv {
+		if v, ok := env[k]; ok {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+	meta := FromContext(ctx)
+	cmd.Env = append(cmd.Env,
+		"DELAY_TASK_NO="+strconv.FormatInt(meta.TaskNo, 10),
+		"DELAY_TENANT="+meta.Tenant,
+		"DELAY_TRACE_ID="+trace.Get(ctx),
+		"DELAY_ATTEMPT="+strconv.Itoa(meta.Attempt),
+	)
+	stdout, stderr := &limitBuffer{max: e.maxOutput()}, &limitBuffer{max: e.maxOutput()}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	setProcessGroup(cmd)
+	// 子进程持有输出管道时，终止后最多再等待该时间
+	cmd.WaitDelay = time.Second
+
+	result := &ExecResult{}
+	if err = cmd.Run(); err == nil {
+		meta.Succeeded = true
+	} else if ctx.Err() != nil {
+		err = fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+	result.ExitCode = cmd.ProcessState.ExitCode()
+	result.Stdout, result.Stderr = stdout.String(), stderr.String()
+	b, _ := json.Marshal(result)
+	return string(b), err
+}
+
+// execArgs 从回调数据中解析 args（字符串数组）与 env（字符串映射）
+func execArgs(data map[string]any) ([]string, map[string]string, error) {
+	var (
+		args []string
+		env  = make(map[string]string)
+	)
+	if v, ok := data["args"]; ok {
+		list, ok := v.([]any)
+		if !ok {
+			return nil, nil, errors.New("exec: data.args must be an array")
+		}
+		for _, a := range list {
+			s, ok := a.(string)
+			if !ok {
+				return nil, nil, errors.New("exec: data.args must be strings")
+			}
+			args = append(args, s)
+		}
+	}
+	if v, ok := data["env"]; ok {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, nil, errors.New("exec: data.env must be an object")
+		}
+		for k, a := range m {
+			s, ok := a.(string)
+			if !ok {
+				return nil, nil, errors.New("exec: data.env values must be strings")
+			}
+			env[k] = s
+		}
+	}
+	return args, env, nil
+}
+
+// limitBuffer 超出上限的输出被丢弃
+type limitBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitBuffer) Write(p []byte) (int, error) {
+	if n := b.max - b.buf.Len(); n < len(p) {
+		b.truncated = true
+		if n > 0 {
+			b.buf.Write(p[:n])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "...(truncated)"
+	}
+	return b.buf.String()
+}
+
+func (e *Exec) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package callback
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup 非 unix 平台仅终止命令进程
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,94 @@
+//go:build unix
+
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newExec() ICallback {
+	return NewExec(setLogger(), &Config{Exec: &ExecConfig{
+		MaxOutput: 16,
+		Commands: []*ExecCommand{{
+			Name:     "sh",
+			Path:     "/bin/sh",
+			Args:     []string{"-c"},
+			Env:      []string{"FIXED=1"},
+			AllowEnv: []string{"NAME"},
+		}},
+	}})
+}
+
+func TestExecRequest(t *testing.T) {
+	e := newExec()
+	meta := &Meta{TaskNo: 42}
+	ctx := NewContext(context.Background(), meta)
+
+	ret, err := e.Request(ctx, &Payload{
+		Path: "sh",
+		Data: map[string]any{
+			"args": []any{`test "$FIXED$NAME$DELAY_TASK_NO" = "1ok42" && test -z "$OTHER" && echo done`},
+			"env":  map[string]any{"NAME": "ok", "OTHER": "x"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result ExecResult
+	if err = json.Unmarshal([]byte(ret), &result); err != nil {
+		t.Fatal(err)
+	}
+	if !meta.Succeeded || result.ExitCode != 0 || result.Stdout != "done\n" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	meta.Succeeded = false
+	ret, err = e.Request(ctx, &Payload{
+		Path: "sh",
+		Data: map[string]any{"args": []any{"echo out; echo 0123456789abcdefghij >&2; exit 3"}},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	result = ExecResult{}
+	if err = json.Unmarshal([]byte(ret), &result); err != nil {
+		t.Fatal(err)
+	}
+	if meta.Succeeded || result.ExitCode != 3 || result.Stdout != "out\n" || result.Stderr != "0123456789abcdef...(truncated)" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if _, err = e.Request(ctx, &Payload{Path: "rm"}); !errors.Is(err, ErrCommandNotAllowed) {
+		t.Fatalf("expected not allowed, got %v", err)
+	}
+	if _, err = e.Request(ctx, &Payload{Path: "sh", Data: map[string]any{"args": "ls"}}); err == nil {
+		t.Fatal("expected invalid args error")
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	e := newExec()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	// 子进程继承输出管道，未终止进程组时会等待到 WaitDelay
+	_, err := e.Request(ctx, &Payload{
+		Path: "sh",
+		Data: map[string]any{"args": []any{"sleep 5 & sleep 5; wait"}},
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if d := time.Since(start); d > 900*time.Millisecond {
+		t.Fatalf("process group not killed, took %s", d)
+	}
+	if !strings.Contains(err.Error(), "killed") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
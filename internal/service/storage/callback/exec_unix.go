@@ -0,0 +1,16 @@
+//go:build unix
+
+package callback
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup 命令在独立进程组中运行，取消时终止整个进程组
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -10,17 +10,8 @@ type Fmt struct {
 	lg log.Logger
 }
 
-func init() {
-	RegisterAdapter("FMT", NewFmt())
-}
-
-func NewFmt() ICallback {
-	return &Fmt{}
-}
-
-func (f *Fmt) SetLogger(lg log.Logger) ICallback {
-	f.lg = lg
-	return f
+func NewFmt(lg log.Logger) ICallback {
+	return &Fmt{lg: lg}
 }
 
 func (f *Fmt) Request(ctx context.Context, payload *Payload) (string, error) {
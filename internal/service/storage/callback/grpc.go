@@ -2,78 +2,265 @@ package callback
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/x-thooh/delay/pkg/log"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type GRPC struct {
 	mu      sync.Mutex
-	clients map[string]*grpc.ClientConn
+	clients map[string]*grpcClient
 	lg      log.Logger
+	cfg     *Config
+	rs      *resolver
+
+	once sync.Once
+	stop chan struct{}
 }
 
-func init() {
-	RegisterAdapter("GRPC", NewGRPC())
+// grpcClient 缓存的连接，refs 为进行中的调用数
+type grpcClient struct {
+	cc   *grpc.ClientConn
+	used time.Time
+	refs int
 }
 
-func NewGRPC() ICallback {
-	return &GRPC{
-		clients: make(map[string]*grpc.ClientConn),
+// NewGRPC 创建 GRPC 回调，加载 cfg.Grpc 中的描述文件集
+func NewGRPC(lg log.Logger, cfg *Config) (ICallback, error) {
+	var gc *GrpcConfig
+	if cfg != nil {
+		gc = cfg.Grpc
+	}
+	rs, err := newResolver(gc)
+	if err != nil {
+		return nil, fmt.Errorf("load grpc descriptor sets: %w", err)
 	}
+	return &GRPC{
+		clients: make(map[string]*grpcClient),
+		lg:      lg,
+		cfg:     cfg,
+		rs:      rs,
+		stop:    make(chan struct{}),
+	}, nil
 }
 
-func (g *GRPC) SetLogger(lg log.Logger) ICallback {
-	g.lg = lg
-	return g
+// pool 返回连接缓存上限与空闲超时
+func (g *GRPC) pool() (int, time.Duration) {
+	maxClients, idle := 256, 10*time.Minute
+	if g.cfg != nil && g.cfg.Grpc != nil {
+		if g.cfg.Grpc.MaxClients > 0 {
+			maxClients = g.cfg.Grpc.MaxClients
+		}
+		if g.cfg.Grpc.IdleTimeout > 0 {
+			idle = g.cfg.Grpc.IdleTimeout
+		}
+	}
+	return maxClients, idle
 }
 
-func (g *GRPC) getClient(url string) (*grpc.ClientConn, error) {
+// getClient 获取连接并增加引用，调用结束后需 release
+func (g *GRPC) getClient(url string, t *Target) (*grpcClient, error) {
+	g.once.Do(func() {
+		go g.evictLoop()
+	})
+
 	g.mu.Lock()
-	if cc, ok := g.clients[url]; ok {
-		g.mu.Unlock()
-		return cc, nil
+	defer g.mu.Unlock()
+	if c, ok := g.clients[url]; ok {
+		c.refs++
+		c.used = time.Now()
+		return c, nil
 	}
-	g.mu.Unlock()
 
-	cc, err := grpc.NewClient(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	opts, err := dialOptions(t)
 	if err != nil {
 		return nil, err
 	}
+	cc, err := grpc.NewClient(t.Grpc.dialTarget(url), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	maxClients, _ := g.pool()
+	if len(g.clients) >= maxClients {
+		g.evictOldest()
+	}
+	c := &grpcClient{cc: cc, used: time.Now(), refs: 1}
+	g.clients[url] = c
+	return c, nil
+}
 
-	g.clients[url] = cc
-	return cc, nil
+func (g *GRPC) release(c *grpcClient) {
+	g.mu.Lock()
+	c.refs--
+	c.used = time.Now()
+	g.mu.Unlock()
+}
+
+// evictOldest 关闭最久未使用且没有进行中调用的连接，需持有锁
+func (g *GRPC) evictOldest() {
+	var (
+		key    string
+		oldest *grpcClient
+	)
+	for k, c := range g.clients {
+		if c.refs == 0 && (oldest == nil || c.used.Before(oldest.used)) {
+			key, oldest = k, c
+		}
+	}
+	if oldest != nil {
+		delete(g.clients, key)
+		_ = oldest.cc.Close()
+	}
+}
+
+// evictLoop 定期关闭空闲连接
+func (g *GRPC) evictLoop() {
+	_, idle := g.pool()
+	ticker := time.NewTicker(idle / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-g.stop:
+			return
+		case <-ticker.C:
+			g.evictIdle(idle)
+		}
+	}
+}
+
+func (g *GRPC) evictIdle(idle time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for k, c := range g.clients {
+		if c.refs == 0 && time.Since(c.used) > idle {
+			delete(g.clients, k)
+			_ = c.cc.Close()
+		}
+	}
 }
 
 func (g *GRPC) Request(ctx context.Context, payload *Payload) (string, error) {
-	cc, err := g.getClient(payload.Url)
+	t := g.cfg.Target(Host(payload.Url))
+	c, err := g.getClient(payload.Url, t)
 	if err != nil {
 		return "", err
 	}
+	defer g.release(c)
+	cc := c.cc
+	ctx = outgoing(ctx, t, payload)
 
-	args, err := structpb.NewStruct(payload.Data)
+	md, err := g.rs.Resolve(ctx, cc, payload.Url, payload.Path)
 	if err != nil {
 		return "", err
 	}
+	if md == nil {
+		// 未找到方法描述，按 Struct -> Value 调用
+		args := &structpb.Struct{}
+		if !payload.empty() {
+			b, err := payload.Message(ctx)
+			if err != nil {
+				return "", err
+			}
+			if err = protojson.Unmarshal(b, args); err != nil {
+				return "", err
+			}
+		}
+
+		reply := new(structpb.Value)
+		if err = cc.Invoke(ctx, payload.Path, args, reply); err != nil {
+			return "", err
+		}
 
-	reply := new(structpb.Value)
-	if err = cc.Invoke(ctx, payload.Path, args, reply); err != nil {
+		return reply.GetStringValue(), nil
+	}
+
+	args := newMessage(md.Input())
+	if !payload.empty() {
+		b, err := payload.Message(ctx)
+		if err != nil {
+			return "", err
+		}
+		if err = (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(b, args); err != nil {
+			return "", err
+		}
+	}
+
+	reply := newMessage(md.Output())
+	method := "/" + string(md.Parent().FullName()) + "/" + string(md.Name())
+	if err = cc.Invoke(ctx, method, args, reply); err != nil {
 		return "", err
 	}
 
-	return reply.GetStringValue(), nil
+	ret, err := g.result(reply)
+	if err != nil {
+		return "", err
+	}
+	// 以完整响应作为回调结果，结果字段只用于判定成功
+	b, err := protojson.Marshal(reply)
+	if err != nil {
+		return "", err
+	}
+	FromContext(ctx).Succeeded = ret == "SUCCESS"
+	return string(b), nil
+}
+
+// newMessage 优先使用已注册的类型，其余使用动态消息
+func newMessage(md protoreflect.MessageDescriptor) proto.Message {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName()); err == nil {
+		return mt.New().Interface()
+	}
+	return dynamicpb.NewMessage(md)
+}
+
+// result 根据响应的结果字段计算回调结果，字段不存在或类型不支持时返回错误
+func (g *GRPC) result(reply proto.Message) (string, error) {
+	if v, ok := reply.(*structpb.Value); ok {
+		return v.GetStringValue(), nil
+	}
+	m := reply.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(g.rs.resultField))
+	if fd == nil {
+		return "", fmt.Errorf("grpc reply %s has no %s field", m.Descriptor().FullName(), g.rs.resultField)
+	}
+	if !fd.IsList() && !fd.IsMap() {
+		switch fd.Kind() {
+		case protoreflect.StringKind:
+			return m.Get(fd).String(), nil
+		case protoreflect.BoolKind:
+			if m.Get(fd).Bool() {
+				return "SUCCESS", nil
+			}
+			return "FAIL", nil
+		}
+	}
+	return "", fmt.Errorf("grpc reply field %s must be string or bool", fd.FullName())
 }
 
 func (g *GRPC) Close(ctx context.Context) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	for _, c := range g.clients {
-		if err := c.Close(); err != nil {
-			return err
+	select {
+	case <-g.stop:
+	default:
+		close(g.stop)
+	}
+	var errs []error
+	for k, c := range g.clients {
+		delete(g.clients, k)
+		if err := c.cc.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close grpc client %s: %w", k, err))
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
@@ -0,0 +1,129 @@
+package callback
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+)
+
+// GrpcDial GRPC 回调目标的连接配置
+type GrpcDial struct {
+	// 附加到每次调用的元数据
+	Metadata map[string]string `yaml:"metadata"`
+	// 每次调用携带 authorization: Bearer <token>，token_file 每次调用时读取（如 k8s service account token）
+	Token     string `yaml:"token"`
+	TokenFile string `yaml:"token_file"`
+	// 允许未启用 TLS 时携带 token（仅限可信内网），默认要求 TLS
+	Insecure bool `yaml:"insecure"`
+	// 负载均衡策略：pick_first（默认）、round_robin；round_robin 时无协议前缀的地址按 dns:/// 解析
+	Balancer  string     `yaml:"balancer"`
+	Keepalive *Keepalive `yaml:"keepalive"`
+}
+
+type Keepalive struct {
+	// 无活动多久后发送 ping
+	Time time.Duration `yaml:"time"`
+	// ping 响应超时
+	Timeout time.Duration `yaml:"timeout"`
+	// 没有进行中的调用时也发送 ping
+	PermitWithoutStream bool `yaml:"permit_without_stream"`
+}
+
+// dialTarget 返回拨号地址
+func (d *GrpcDial) dialTarget(url string) string {
+	if d == nil || d.Balancer != "round_robin" || strings.Contains(url, "://") {
+		return url
+	}
+	return "dns:///" + url
+}
+
+// dialOptions 构造目标的拨号选项
+func dialOptions(t *Target) ([]grpc.DialOption, error) {
+	creds := insecure.NewCredentials()
+	if t.TLS.Enabled() {
+		tc, err := t.TLS.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tc)
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+
+	d := t.Grpc
+	if d == nil {
+		return opts, nil
+	}
+	if d.Token != "" || d.TokenFile != "" {
+		if !t.TLS.Enabled() && !d.Insecure {
+			return nil, fmt.Errorf("grpc token for %s requires tls, set insecure to send it in plaintext", t.Host)
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(&tokenCreds{
+			token:  d.Token,
+			file:   d.TokenFile,
+			secure: !d.Insecure,
+		}))
+	}
+	switch d.Balancer {
+	case "", "pick_first":
+	case "round_robin":
+		opts = append(opts, grpc.WithDefaultServiceConfig(`{"loadBalancingConfig":[{"round_robin":{}}]}`))
+	default:
+		return nil, fmt.Errorf("unsupported grpc balancer %q", d.Balancer)
+	}
+	if k := d.Keepalive; k != nil && k.Time > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                k.Time,
+			Timeout:             k.Timeout,
+			PermitWithoutStream: k.PermitWithoutStream,
+		}))
+	}
+	return opts, nil
+}
+
+// outgoing 附加调用元数据：目标配置、任务请求头、链路及任务信息
+func outgoing(ctx context.Context, t *Target, payload *Payload) context.Context {
+	md := metadata.MD{}
+	if t.Grpc != nil {
+		for k, v := range t.Grpc.Metadata {
+			md.Set(k, v)
+		}
+	}
+	for k, v := range payload.Headers {
+		md.Set(k, v)
+	}
+	for k, v := range MetaHeaders(ctx) {
+		md.Set(k, v)
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+type tokenCreds struct {
+	token  string
+	file   string
+	secure bool
+}
+
+func (c *tokenCreds) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	token := c.token
+	if c.file != "" {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity 除非目标显式配置 insecure，否则要求安全连接
+func (c *tokenCreds) RequireTransportSecurity() bool {
+	return c.secure
+}
@@ -0,0 +1,268 @@
+package callback
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// GrpcConfig GRPC 回调方法描述来源
+type GrpcConfig struct {
+	// FileDescriptorSet 文件（protoc --include_imports --descriptor_set_out）
+	DescriptorSets []string `yaml:"descriptor_sets"`
+	// 通过服务端反射获取方法描述
+	Reflection bool `yaml:"reflection"`
+	// 响应中表示结果的字段（string 或 bool），默认 result；字段不存在时视为失败
+	ResultField string `yaml:"result_field"`
+	// 未找到的方法描述缓存时间，过期后重新查找，默认 1m
+	NegativeTTL time.Duration `yaml:"negative_ttl"`
+	// 连接缓存上限，默认 256，超出时关闭最久未使用的连接
+	MaxClients int `yaml:"max_clients"`
+	// 连接空闲超过该时间后关闭，默认 10m
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+}
+
+// resolver 解析 GRPC 方法的请求、响应类型
+type resolver struct {
+	files       *protoregistry.Files
+	reflection  bool
+	resultField string
+	negativeTTL time.Duration
+
+	mu      sync.Mutex
+	methods map[string]*resolved
+}
+
+// resolved 缓存的方法描述，md 为空表示未找到，到期后重新查找
+type resolved struct {
+	md       protoreflect.MethodDescriptor
+	expireAt time.Time
+}
+
+func newResolver(cfg *GrpcConfig) (*resolver, error) {
+	r := &resolver{
+		resultField: "result",
+		negativeTTL: time.Minute,
+		methods:     make(map[string]*resolved),
+	}
+	if cfg == nil {
+		return r, nil
+	}
+	r.reflection = cfg.Reflection
+	if cfg.ResultField != "" {
+		r.resultField = cfg.ResultField
+	}
+	if cfg.NegativeTTL > 0 {
+		r.negativeTTL = cfg.NegativeTTL
+	}
+	if len(cfg.DescriptorSets) == 0 {
+		return r, nil
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range cfg.DescriptorSets {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		s := &descriptorpb.FileDescriptorSet{}
+		if err = proto.Unmarshal(b, s); err != nil {
+			return nil, fmt.Errorf("parse descriptor set %s: %w", file, err)
+		}
+		set.File = append(set.File, s.File...)
+	}
+	files, err := buildFiles(set.File)
+	if err != nil {
+		return nil, err
+	}
+	r.files = files
+	return r, nil
+}
+
+// Resolve 查找方法描述，未找到时返回 nil
+func (r *resolver) Resolve(ctx context.Context, cc *grpc.ClientConn, target, method string) (protoreflect.MethodDescriptor, error) {
+	service, name, err := splitMethod(method)
+	if err != nil {
+		return nil, err
+	}
+	key := target + "/" + service + "/" + name
+	r.mu.Lock()
+	c, ok := r.methods[key]
+	r.mu.Unlock()
+	if ok && (c.md != nil || time.Now().Before(c.expireAt)) {
+		return c.md, nil
+	}
+
+	var md protoreflect.MethodDescriptor
+	if r.files != nil {
+		md = findMethod(r.files, service, name)
+	}
+	if md == nil && r.reflection {
+		files, err := reflectFiles(ctx, cc, service)
+		switch {
+		case err == nil:
+			md = findMethod(files, service, name)
+		case status.Code(err) != codes.Unimplemented && status.Code(err) != codes.NotFound:
+			return nil, fmt.Errorf("reflect %s: %w", service, err)
+		}
+	}
+	// 未找到的方法缓存一段时间，避免每次回调都请求反射，服务端上线新方法后可重新获取
+	c = &resolved{md: md}
+	if md == nil {
+		c.expireAt = time.Now().Add(r.negativeTTL)
+	}
+	r.mu.Lock()
+	r.methods[key] = c
+	r.mu.Unlock()
+	return md, nil
+}
+
+func findMethod(files *protoregistry.Files, service, name string) protoreflect.MethodDescriptor {
+	d, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	return sd.Methods().ByName(protoreflect.Name(name))
+}
+
+// splitMethod 解析 "pkg.Service/Method" 或 "/pkg.Service/Method"
+func splitMethod(method string) (string, string, error) {
+	method = strings.TrimPrefix(method, "/")
+	i := strings.LastIndex(method, "/")
+	if i <= 0 || i == len(method)-1 {
+		return "", "", fmt.Errorf("invalid grpc method %q", method)
+	}
+	return method[:i], method[i+1:], nil
+}
+
+// reflectFiles 通过服务端反射获取服务所在文件及其依赖
+func reflectFiles(ctx context.Context, cc *grpc.ClientConn, service string) (*protoregistry.Files, error) {
+	stream, err := rpb.NewServerReflectionClient(cc).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = stream.CloseSend()
+	}()
+
+	var (
+		fds     []*descriptorpb.FileDescriptorProto
+		seen    = make(map[string]bool)
+		pending = []*rpb.ServerReflectionRequest{{
+			MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+		}}
+	)
+	for len(pending) > 0 {
+		req := pending[0]
+		pending = pending[1:]
+		if err = stream.Send(req); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+		}
+		for _, b := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err = proto.Unmarshal(b, fd); err != nil {
+				return nil, err
+			}
+			if seen[fd.GetName()] {
+				continue
+			}
+			seen[fd.GetName()] = true
+			fds = append(fds, fd)
+		}
+		// 补齐服务端未返回的依赖
+		for _, fd := range fds {
+			for _, dep := range fd.GetDependency() {
+				if seen[dep] {
+					continue
+				}
+				if _, err = protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+					continue
+				}
+				seen[dep] = true
+				pending = append(pending, &rpb.ServerReflectionRequest{
+					MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+				})
+			}
+		}
+	}
+	return buildFiles(fds)
+}
+
+// buildFiles 按依赖顺序注册文件，缺失的依赖从全局注册表中查找
+func buildFiles(fds []*descriptorpb.FileDescriptorProto) (*protoregistry.Files, error) {
+	byName := make(map[string]*descriptorpb.FileDescriptorProto, len(fds))
+	for _, fd := range fds {
+		byName[fd.GetName()] = fd
+	}
+	files := &protoregistry.Files{}
+	res := &chainResolver{files}
+	var register func(name string) error
+	register = func(name string) error {
+		if _, err := files.FindFileByPath(name); err == nil {
+			return nil
+		}
+		fd, ok := byName[name]
+		if !ok {
+			// 全局注册表中的文件（如 google/protobuf/*）
+			_, err := protoregistry.GlobalFiles.FindFileByPath(name)
+			return err
+		}
+		for _, dep := range fd.GetDependency() {
+			if err := register(dep); err != nil {
+				return err
+			}
+		}
+		f, err := protodesc.NewFile(fd, res)
+		if err != nil {
+			return fmt.Errorf("build %s: %w", name, err)
+		}
+		return files.RegisterFile(f)
+	}
+	for _, fd := range fds {
+		if err := register(fd.GetName()); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+type chainResolver struct {
+	files *protoregistry.Files
+}
+
+func (c *chainResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if f, err := c.files.FindFileByPath(path); err == nil {
+		return f, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (c *chainResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := c.files.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
@@ -0,0 +1,337 @@
+package callback
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/x-thooh/delay/pkg/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// testFile order.v1.Order/Pay(PayRequest) returns (PayReply)
+func testFile() *descriptorpb.FileDescriptorProto {
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(num),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+		}
+	}
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("order/v1/order.proto"),
+		Package: proto.String("order.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("PayRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("order_id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("amount", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+			}},
+			{Name: proto.String("PayReply"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("result", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Order"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Pay"),
+				InputType:  proto.String(".order.v1.PayRequest"),
+				OutputType: proto.String(".order.v1.PayReply"),
+			}},
+		}},
+	}
+}
+
+type orderCall struct {
+	in protoreflect.Message
+	md metadata.MD
+}
+
+// startOrder 启动使用动态消息实现的 GRPC 服务
+func startOrder(t *testing.T, withReflection bool) (string, chan *orderCall) {
+	fd, err := protodesc.NewFile(testFile(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := &protoregistry.Files{}
+	if err = files.RegisterFile(fd); err != nil {
+		t.Fatal(err)
+	}
+	md := fd.Services().Get(0).Methods().Get(0)
+
+	got := make(chan *orderCall, 1)
+	s := grpc.NewServer()
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "order.v1.Order",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Pay",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				in := dynamicpb.NewMessage(md.Input())
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				h, _ := metadata.FromIncomingContext(ctx)
+				got <- &orderCall{in: in, md: h}
+				out := dynamicpb.NewMessage(md.Output())
+				out.Set(md.Output().Fields().ByName("result"), protoreflect.ValueOfString("SUCCESS"))
+				return out, nil
+			},
+		}},
+	}, struct{}{})
+	if withReflection {
+		rpb.RegisterServerReflectionServer(s, reflection.NewServerV1(reflection.ServerOptions{
+			Services:           s,
+			DescriptorResolver: files,
+		}))
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+	return lis.Addr().String(), got
+}
+
+func checkPay(t *testing.T, cfg *Config, addr string, got chan *orderCall) {
+	g, err := NewGRPC(setLogger(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = g.Close(context.Background())
+	}()
+
+	meta := &Meta{}
+	ret, err := g.Request(NewContext(context.Background(), meta), &Payload{
+		Schema: "GRPC",
+		Url:    addr,
+		Path:   "/order.v1.Order/Pay",
+		Data:   map[string]interface{}{"order_id": "A001", "amount": 100, "unknown": true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !meta.Succeeded || !strings.Contains(ret, `"SUCCESS"`) {
+		t.Fatalf("unexpected result %q %v", ret, meta.Succeeded)
+	}
+	in := (<-got).in
+	fields := in.Descriptor().Fields()
+	if v := in.Get(fields.ByName("order_id")).String(); v != "A001" {
+		t.Fatalf("unexpected order_id %q", v)
+	}
+	if v := in.Get(fields.ByName("amount")).Int(); v != 100 {
+		t.Fatalf("unexpected amount %d", v)
+	}
+}
+
+func TestGrpcReflection(t *testing.T) {
+	addr, got := startOrder(t, true)
+	checkPay(t, &Config{Grpc: &GrpcConfig{Reflection: true}}, addr, got)
+}
+
+func TestGrpcDescriptorSet(t *testing.T) {
+	addr, got := startOrder(t, false)
+
+	b, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{testFile()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "order.pb")
+	if err = os.WriteFile(file, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	checkPay(t, &Config{Grpc: &GrpcConfig{DescriptorSets: []string{file}}}, addr, got)
+}
+
+func TestGrpcMetadata(t *testing.T) {
+	addr, got := startOrder(t, true)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("abc\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGRPC(setLogger(), &Config{
+		Grpc: &GrpcConfig{Reflection: true},
+		Targets: []*Target{{Host: "*", Grpc: &GrpcDial{
+			Metadata:  map[string]string{"X-App": "delay"},
+			TokenFile: tokenFile,
+			Insecure:  true,
+			Balancer:  "round_robin",
+			Keepalive: &Keepalive{Time: time.Minute, Timeout: time.Second},
+		}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = g.Close(context.Background())
+	}()
+
+	ctx := NewContext(trace.Set(context.Background(), "t-1"), &Meta{TaskNo: 42, Tenant: "order"})
+	if _, err := g.Request(ctx, &Payload{
+		Url:     addr,
+		Path:    "/order.v1.Order/Pay",
+		Headers: map[string]string{"X-Custom": "1"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	md := (<-got).md
+	want := map[string]string{
+		"x-app":           "delay",
+		"x-custom":        "1",
+		"x-trace-id":      "t-1",
+		"x-delay-task-no": "42",
+		"x-tenant-id":     "order",
+		"authorization":   "Bearer abc",
+	}
+	for k, v := range want {
+		if got := md.Get(k); len(got) != 1 || got[0] != v {
+			t.Fatalf("metadata %s = %v, want %s", k, got, v)
+		}
+	}
+	// 未启用 TLS 时默认不允许携带 token
+	plain, err := NewGRPC(setLogger(), &Config{
+		Targets: []*Target{{Host: "*", Grpc: &GrpcDial{Token: "abc"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = plain.Close(context.Background())
+	}()
+	if _, err = plain.Request(ctx, &Payload{Url: addr, Path: "/order.v1.Order/Pay"}); err == nil {
+		t.Fatal("expected token over plaintext to be rejected")
+	}
+}
+
+func TestGrpcClientCache(t *testing.T) {
+	c, err := NewGRPC(nil, &Config{Grpc: &GrpcConfig{MaxClients: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := c.(*GRPC)
+	defer func() {
+		_ = g.Close(context.Background())
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := g.getClient("127.0.0.1:1", &Target{})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			g.release(c)
+		}()
+	}
+	wg.Wait()
+	if len(g.clients) != 1 {
+		t.Fatalf("unexpected clients %d", len(g.clients))
+	}
+
+	for _, url := range []string{"127.0.0.1:2", "127.0.0.1:3"} {
+		c, err := g.getClient(url, &Target{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.release(c)
+	}
+	if _, ok := g.clients["127.0.0.1:1"]; ok || len(g.clients) != 2 {
+		t.Fatalf("oldest client not evicted: %d", len(g.clients))
+	}
+
+	g.evictIdle(0)
+	if len(g.clients) != 0 {
+		t.Fatalf("idle clients not evicted: %d", len(g.clients))
+	}
+}
+
+func TestSplitMethod(t *testing.T) {
+	service, name, err := splitMethod("/order.v1.Order/Pay")
+	if err != nil || service != "order.v1.Order" || name != "Pay" {
+		t.Fatalf("unexpected %q %q %v", service, name, err)
+	}
+	if _, _, err = splitMethod("order.v1.Order"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestGrpcResult(t *testing.T) {
+	fd, err := protodesc.NewFile(testFile(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := newResolver(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &GRPC{rs: rs}
+	reply := dynamicpb.NewMessage(fd.Messages().ByName("PayReply"))
+	reply.Set(reply.Descriptor().Fields().ByName("result"), protoreflect.ValueOfString("FAIL"))
+	if ret, err := g.result(reply); err != nil || ret != "FAIL" {
+		t.Fatalf("unexpected result %q %v", ret, err)
+	}
+	// 响应中没有结果字段
+	if _, err = g.result(dynamicpb.NewMessage(fd.Messages().ByName("PayRequest"))); err == nil {
+		t.Fatal("expected error for missing result field")
+	}
+	g.rs.resultField = "amount"
+	if _, err = g.result(dynamicpb.NewMessage(fd.Messages().ByName("PayRequest"))); err == nil {
+		t.Fatal("expected error for unsupported result field")
+	}
+}
+
+func TestResolverNegativeTTL(t *testing.T) {
+	rs, err := newResolver(&GrpcConfig{NegativeTTL: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md, err := rs.Resolve(context.Background(), nil, "127.0.0.1:1", "/order.v1.Order/Pay"); err != nil || md != nil {
+		t.Fatalf("unexpected %v %v", md, err)
+	}
+	key := "127.0.0.1:1/order.v1.Order/Pay"
+	first := rs.methods[key]
+	if first == nil || first.md != nil || first.expireAt.IsZero() {
+		t.Fatalf("expected negative entry, got %+v", first)
+	}
+
+	// 描述加载后，负缓存过期即可找到
+	fd, err := protodesc.NewFile(testFile(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs.files = &protoregistry.Files{}
+	if err = rs.files.RegisterFile(fd); err != nil {
+		t.Fatal(err)
+	}
+	if md, _ := rs.Resolve(context.Background(), nil, "127.0.0.1:1", "/order.v1.Order/Pay"); md != nil {
+		t.Fatal("negative entry should be cached")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if md, _ := rs.Resolve(context.Background(), nil, "127.0.0.1:1", "/order.v1.Order/Pay"); md == nil {
+		t.Fatal("expected method after negative entry expired")
+	}
+}
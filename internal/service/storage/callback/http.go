@@ -3,46 +3,67 @@ package callback
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
-	"strings"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/x-thooh/delay/pkg/log"
+	"github.com/x-thooh/delay/pkg/signature"
 )
 
 type Http struct {
-	client *http.Client
-	lg     log.Logger
+	mu      sync.Mutex
+	clients map[string]*http.Client
+	lg      log.Logger
+	cfg     *Config
 }
 
-func init() {
-	RegisterAdapter("HTTPS", NewHttp())
-	RegisterAdapter("HTTP", NewHttp())
-}
-
-func NewHttp() ICallback {
+func NewHttp(lg log.Logger, cfg *Config) ICallback {
 	return &Http{
-		client: &http.Client{
-			Timeout: 5 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:      100,                                   // 最大空闲连接数
-				MaxConnsPerHost:   1000,                                  // 每个主机最多连接数
-				IdleConnTimeout:   90 * time.Second,                      // 空闲连接超时
-				DisableKeepAlives: false,                                 // 是否禁用长连接
-				TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, // HTTPS 忽略证书
-			},
-		},
+		clients: make(map[string]*http.Client),
+		lg:      lg,
+		cfg:     cfg,
 	}
 }
 
-func (h *Http) SetLogger(lg log.Logger) ICallback {
-	h.lg = lg
-	return h
+// getClient 按回调目标 host 获取客户端，每个 host 独立连接池；
+// 客户端不设置整体超时，由任务 timeout 对应的 ctx 截止时间控制
+func (h *Http) getClient(host string) (*http.Client, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c, ok := h.clients[host]; ok {
+		return c, nil
+	}
+	t := h.cfg.Target(host)
+	tc, err := t.TLS.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	hc := h.cfg.HttpConfig(t)
+	c := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   hc.ConnectTimeout,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSHandshakeTimeout:   hc.TLSHandshakeTimeout,
+			ResponseHeaderTimeout: hc.ResponseHeaderTimeout,
+			IdleConnTimeout:       hc.IdleConnTimeout,
+			MaxIdleConns:          hc.MaxIdleConnsPerHost,
+			MaxIdleConnsPerHost:   hc.MaxIdleConnsPerHost,
+			MaxConnsPerHost:       hc.MaxConnsPerHost,
+			TLSClientConfig:       tc,
+		},
+	}
+	h.clients[host] = c
+	return c, nil
 }
 
 func (h *Http) Request(ctx context.Context, payload *Payload) (ret string, err error) {
@@ -62,36 +83,52 @@ func (h *Http) Request(ctx context.Context, payload *Payload) (ret string, err e
 		}
 	}()
 
-	reader, err := MapToReader(payload.Data)
+	contentType, body, err := payload.Encode(ctx)
 	if err != nil {
 		return "", err
 	}
-
-	url := fmt.Sprintf("%s%s", payload.Url, payload.Path)
-	if !strings.HasPrefix(url, "http") {
-		url = fmt.Sprintf("%s://%s", strings.ToLower(payload.Schema), url)
+	url, err := payload.RequestUrl()
+	if err != nil {
+		return "", err
+	}
+	method := payload.HttpMethod()
+	var reader io.Reader
+	if method != http.MethodGet && method != http.MethodHead {
+		reader = bytes.NewReader(body)
+	} else {
+		body = nil
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, reader)
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
 		return "", err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range payload.Headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range MetaHeaders(ctx) {
+		req.Header.Set(k, v)
+	}
+	h.sign(req, payload, body)
 
-	resp, err := h.client.Do(req)
+	host := Host(url)
+	client, err := h.getClient(host)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
 	}
 	defer func() {
-		if err = resp.Body.Close(); err != nil {
-			return
-		}
+		_ = resp.Body.Close()
 	}()
 
-	body, err := io.ReadAll(resp.Body)
+	rb, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return "", err
 	}
-	ret = string(body)
+	ret = string(rb)
 
 	// --- 2. 打印响应 ---
 	h.lg.Info(ctx, "HTTP Response",
@@ -101,11 +138,33 @@ func (h *Http) Request(ctx context.Context, payload *Payload) (ret string, err e
 		slog.Duration("cost", time.Since(start)),
 	)
 
+	if h.cfg.HttpConfig(h.cfg.Target(host)).Success == "status" {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return ret, fmt.Errorf("http status %d", resp.StatusCode)
+		}
+		FromContext(ctx).Succeeded = true
+	}
 	return ret, nil
 }
 
+// sign 签名请求体，并附带时间戳
+func (h *Http) sign(req *http.Request, payload *Payload, body []byte) {
+	meta := FromContext(req.Context())
+	secret := h.cfg.Secret(payload, meta.Tenant)
+	if secret == "" {
+		return
+	}
+	ts := time.Now().Unix()
+	req.Header.Set(signature.HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(signature.HeaderSignature, signature.Sign(secret, ts, body))
+}
+
 func (h *Http) Close(ctx context.Context) error {
-	h.client.CloseIdleConnections()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, c := range h.clients {
+		c.CloseIdleConnections()
+	}
 	return nil
 }
 
@@ -0,0 +1,159 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/x-thooh/delay/pkg/log"
+	"github.com/x-thooh/delay/pkg/log/xslog"
+	"github.com/x-thooh/delay/pkg/signature"
+)
+
+func setLogger() log.Logger {
+	lg, _, _ := xslog.New(&log.Config{Model: "std", Level: "error", Format: "text"})
+	return lg
+}
+
+func TestHttpRequest(t *testing.T) {
+	var (
+		method, query, contentType, custom, sig, ts, taskNo string
+		body                                                []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, query = r.Method, r.URL.RawQuery
+		contentType, custom = r.Header.Get("Content-Type"), r.Header.Get("X-Custom")
+		sig, ts, taskNo = r.Header.Get(signature.HeaderSignature), r.Header.Get(signature.HeaderTimestamp), r.Header.Get(signature.HeaderTaskNo)
+		body, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte("SUCCESS"))
+	}))
+	defer srv.Close()
+
+	h := NewHttp(setLogger(), &Config{Signing: &Signing{Secret: "secret"}})
+	ctx := NewContext(context.Background(), &Meta{TaskNo: 42, Tenant: "order"})
+	ret, err := h.Request(ctx, &Payload{
+		Schema:      "HTTP",
+		Url:         srv.URL,
+		Path:        "/hook",
+		Data:        map[string]any{"order_id": "A1", "amount": 3},
+		Method:      "put",
+		Headers:     map[string]string{"X-Custom": "yes"},
+		Query:       map[string]string{"source": "delay"},
+		ContentType: ContentTypeForm,
+		Template:    `order={{.Data.order_id}}&task={{.TaskNo}}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret != "SUCCESS" {
+		t.Fatalf("unexpected response %q", ret)
+	}
+	if method != http.MethodPut || query != "source=delay" || custom != "yes" {
+		t.Fatalf("unexpected request %s ?%s custom=%s", method, query, custom)
+	}
+	if contentType != "application/x-www-form-urlencoded" || string(body) != "order=A1&task=42" {
+		t.Fatalf("unexpected body %s %s", contentType, body)
+	}
+	if taskNo != "42" {
+		t.Fatalf("unexpected task no %q", taskNo)
+	}
+	if err = signature.Verify("secret", ts, sig, body, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = strconv.ParseInt(ts, 10, 64); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPayloadEncode(t *testing.T) {
+	ct, body, err := (&Payload{Data: map[string]any{"b": 2, "a": "x"}, ContentType: ContentTypeForm}).Encode(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct != "application/x-www-form-urlencoded" || string(body) != "a=x&b=2" {
+		t.Fatalf("unexpected form %s %s", ct, body)
+	}
+	if _, _, err = (&Payload{ContentType: "xml"}).Encode(context.Background()); err == nil {
+		t.Fatal("expected unsupported content type error")
+	}
+
+	// json 模板中的字符串需要转义
+	data := map[string]any{"name": `a"b`, "n": 1}
+	if _, _, err = (&Payload{Data: data, Template: `{"name":"{{.Data.name}}"}`}).Encode(context.Background()); err == nil {
+		t.Fatal("expected invalid json error")
+	}
+	ct, body, err = (&Payload{Data: data, Template: `{"name":{{json .Data.name}},"n":{{json .Data.n}}}`}).Encode(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct != "application/json" || string(body) != `{"name":"a\"b","n":1}` {
+		t.Fatalf("unexpected json %s %s", ct, body)
+	}
+}
+
+func TestHttpRequestDeadline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		_, _ = w.Write([]byte("SUCCESS"))
+	}))
+	defer srv.Close()
+
+	h := NewHttp(setLogger(), nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := h.Request(ctx, &Payload{Schema: "HTTP", Url: srv.URL}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if ret, err := h.Request(ctx, &Payload{Schema: "HTTP", Url: srv.URL}); err != nil || ret != "SUCCESS" {
+		t.Fatalf("unexpected result %q %v", ret, err)
+	}
+}
+
+func TestHttpSuccessStatus(t *testing.T) {
+	code := http.StatusCreated
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(`{"charge_id":"c-1"}`))
+	}))
+	defer srv.Close()
+
+	h := NewHttp(setLogger(), &Config{Targets: []*Target{{Host: Host(srv.URL), Http: &HttpConfig{Success: "status"}}}})
+	meta := &Meta{}
+	ret, err := h.Request(NewContext(context.Background(), meta), &Payload{Schema: "HTTP", Url: srv.URL})
+	if err != nil || !meta.Succeeded || ret != `{"charge_id":"c-1"}` {
+		t.Fatalf("unexpected result %q %v %v", ret, meta.Succeeded, err)
+	}
+
+	code = http.StatusInternalServerError
+	meta = &Meta{}
+	if _, err = h.Request(NewContext(context.Background(), meta), &Payload{Schema: "HTTP", Url: srv.URL}); err == nil || meta.Succeeded {
+		t.Fatalf("expected failure, got %v %v", meta.Succeeded, err)
+	}
+
+	// 默认按响应体判断，由调用方判定
+	meta = &Meta{}
+	if _, err = NewHttp(setLogger(), nil).Request(NewContext(context.Background(), meta), &Payload{Schema: "HTTP", Url: srv.URL}); err != nil || meta.Succeeded {
+		t.Fatalf("unexpected result %v %v", meta.Succeeded, err)
+	}
+}
+
+func TestHttpConfig(t *testing.T) {
+	cfg := &Config{
+		Http: &HttpConfig{ConnectTimeout: time.Second, MaxConnsPerHost: 10},
+		Targets: []*Target{
+			{Host: "slow.example.com", Http: &HttpConfig{ResponseHeaderTimeout: time.Minute}},
+		},
+	}
+	hc := cfg.HttpConfig(cfg.Target("slow.example.com"))
+	if hc.ConnectTimeout != time.Second || hc.MaxConnsPerHost != 10 || hc.ResponseHeaderTimeout != time.Minute || hc.MaxIdleConnsPerHost != 100 {
+		t.Fatalf("unexpected config %+v", hc)
+	}
+}
@@ -0,0 +1,137 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/x-thooh/delay/pkg/kafka"
+	"github.com/x-thooh/delay/pkg/log"
+)
+
+// KafkaConfig KAFKA 回调生产者配置，TLS 按首个 broker 地址从 targets 中查找
+type KafkaConfig struct {
+	ClientID string `yaml:"client_id"`
+	// 确认级别：all（默认）、leader、none
+	Acks string `yaml:"acks"`
+	// broker 端等待副本确认的超时
+	Timeout     time.Duration `yaml:"timeout"`
+	DialTimeout time.Duration `yaml:"dial_timeout"`
+	// 无 leader 等可重试错误的重试次数及首次退避时间
+	Retries int           `yaml:"retries"`
+	Backoff time.Duration `yaml:"backoff"`
+}
+
+// Kafka 将回调数据发送到主题：url 为逗号分隔的 broker 地址，path 为主题，broker 确认即成功
+type Kafka struct {
+	mu        sync.Mutex
+	producers map[string]*kafka.Producer
+	lg        log.Logger
+	cfg       *Config
+}
+
+func NewKafka(lg log.Logger, cfg *Config) ICallback {
+	return &Kafka{
+		producers: make(map[string]*kafka.Producer),
+		lg:        lg,
+		cfg:       cfg,
+	}
+}
+
+func (k *Kafka) getProducer(url string) (*kafka.Producer, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if p, ok := k.producers[url]; ok {
+		return p, nil
+	}
+
+	brokers := strings.Split(strings.TrimPrefix(url, "kafka://"), ",")
+	pc := &kafka.Config{Brokers: brokers}
+	if k.cfg != nil && k.cfg.Kafka != nil {
+		pc.ClientID = k.cfg.Kafka.ClientID
+		pc.Acks = k.cfg.Kafka.Acks
+		pc.Timeout = k.cfg.Kafka.Timeout
+		pc.DialTimeout = k.cfg.Kafka.DialTimeout
+		pc.Retries = k.cfg.Kafka.Retries
+		pc.Backoff = k.cfg.Kafka.Backoff
+	}
+	if t := k.cfg.Target(Host(brokers[0])); t.TLS.Enabled() {
+		tc, err := t.TLS.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		pc.TLS = tc
+	}
+	p, err := kafka.NewProducer(pc)
+	if err != nil {
+		return nil, err
+	}
+	k.producers[url] = p
+	return p, nil
+}
+
+func (k *Kafka) Request(ctx context.Context, payload *Payload) (string, error) {
+	p, err := k.getProducer(payload.Url)
+	if err != nil {
+		return "", err
+	}
+
+	contentType, body, err := payload.Encode(ctx)
+	if err != nil {
+		return "", err
+	}
+	meta := FromContext(ctx)
+	key := payload.Key
+	if key == "" && meta.TaskNo > 0 {
+		key = strconv.FormatInt(meta.TaskNo, 10)
+	}
+
+	msg := &kafka.Message{
+		Topic:   strings.TrimPrefix(payload.Path, "/"),
+		Value:   body,
+		Headers: messageHeaders(ctx, payload, contentType),
+	}
+	if key != "" {
+		msg.Key = []byte(key)
+	}
+	if _, _, err = p.Send(ctx, msg); err != nil {
+		return "", err
+	}
+	return "SUCCESS", nil
+}
+
+// messageHeaders 消息头：内容类型、任务请求头、链路及任务信息
+func messageHeaders(ctx context.Context, payload *Payload, contentType string) []kafka.Header {
+	headers := []kafka.Header{{Key: "Content-Type", Value: []byte(contentType)}}
+	for k, v := range payload.Headers {
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	meta := MetaHeaders(ctx)
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(meta[k])})
+	}
+	return headers
+}
+
+func (k *Kafka) Close(ctx context.Context) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	var errs []error
+	for url, p := range k.producers {
+		delete(k.producers, url)
+		if err := p.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close kafka producer %s: %w", url, err))
+		}
+	}
+	return errors.Join(errs...)
+}
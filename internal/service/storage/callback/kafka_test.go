@@ -0,0 +1,70 @@
+package callback
+
+import (
+	"context"
+	"testing"
+
+	"github.com/x-thooh/delay/pkg/kafka/kafkatest"
+	"github.com/x-thooh/delay/pkg/trace"
+)
+
+func TestKafkaRequest(t *testing.T) {
+	b, err := kafkatest.NewBroker(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	k := NewKafka(setLogger(), &Config{Kafka: &KafkaConfig{Acks: "leader"}})
+	defer func() {
+		_ = k.Close(context.Background())
+	}()
+
+	ctx := NewContext(trace.Set(context.Background(), "t-1"), &Meta{TaskNo: 42, Tenant: "order"})
+	ret, err := k.Request(ctx, &Payload{
+		Schema:  "KAFKA",
+		Url:     b.Addr(),
+		Path:    "order.paid",
+		Data:    map[string]any{"order_id": "A001"},
+		Headers: map[string]string{"x-custom": "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret != "SUCCESS" {
+		t.Fatalf("unexpected result %q", ret)
+	}
+
+	records := b.Records("order.paid", 0)
+	if len(records) != 1 {
+		t.Fatalf("unexpected records %d", len(records))
+	}
+	r := records[0]
+	if string(r.Key) != "42" || string(r.Value) != `{"order_id":"A001"}` {
+		t.Fatalf("unexpected record %s %s", r.Key, r.Value)
+	}
+	headers := make(map[string]string)
+	for _, h := range r.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	want := map[string]string{
+		"Content-Type":    "application/json",
+		"x-custom":        "1",
+		"X-Trace-ID":      "t-1",
+		"X-Delay-Task-No": "42",
+		"X-Tenant-Id":     "order",
+	}
+	for key, v := range want {
+		if headers[key] != v {
+			t.Fatalf("header %s = %q, want %q", key, headers[key], v)
+		}
+	}
+
+	// 指定消息键
+	if _, err = k.Request(ctx, &Payload{Url: b.Addr(), Path: "order.paid", Key: "A001"}); err != nil {
+		t.Fatal(err)
+	}
+	if records = b.Records("order.paid", 0); string(records[1].Key) != "A001" {
+		t.Fatalf("unexpected key %s", records[1].Key)
+	}
+}
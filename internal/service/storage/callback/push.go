@@ -0,0 +1,55 @@
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/x-thooh/delay/internal/service/push"
+	"github.com/x-thooh/delay/pkg/log"
+	"github.com/x-thooh/delay/pkg/trace"
+)
+
+var ErrNoSubscriber = errors.New("push: no live subscriber")
+
+// Push 推送给本节点频道的在线订阅者：path 为频道，url 不使用，至少投递给一个订阅者即成功
+type Push struct {
+	hub *push.Hub
+	lg  log.Logger
+}
+
+func NewPush(lg log.Logger, hub *push.Hub) ICallback {
+	return &Push{lg: lg, hub: hub}
+}
+
+func (p *Push) Request(ctx context.Context, payload *Payload) (string, error) {
+	contentType, body, err := payload.Encode(ctx)
+	if err != nil {
+		return "", err
+	}
+	data := json.RawMessage(body)
+	if !strings.HasPrefix(contentType, "application/json") || !json.Valid(body) {
+		// 非 JSON 请求体作为字符串推送
+		if data, err = json.Marshal(string(body)); err != nil {
+			return "", err
+		}
+	}
+
+	meta := FromContext(ctx)
+	n := p.hub.Publish(push.Key(meta.Tenant, payload.Path), &push.Message{
+		Channel:     payload.Path,
+		TaskNo:      meta.TaskNo,
+		TraceId:     trace.Get(ctx),
+		ContentType: contentType,
+		Data:        data,
+	})
+	if n == 0 {
+		return "", ErrNoSubscriber
+	}
+	return "SUCCESS", nil
+}
+
+func (p *Push) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,38 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/x-thooh/delay/internal/service/push"
+)
+
+func TestPushRequest(t *testing.T) {
+	hub := push.NewHub()
+	p := NewPush(setLogger(), hub)
+	ctx := NewContext(context.Background(), &Meta{TaskNo: 42, Tenant: "order"})
+
+	if _, err := p.Request(ctx, &Payload{Path: "countdown"}); !errors.Is(err, ErrNoSubscriber) {
+		t.Fatalf("expected no subscriber, got %v", err)
+	}
+
+	sub := hub.Subscribe(1, push.Key("order", "countdown"))
+	defer hub.Unsubscribe(sub)
+	ret, err := p.Request(ctx, &Payload{Path: "countdown", Data: map[string]any{"order_id": "A001"}})
+	if err != nil || ret != "SUCCESS" {
+		t.Fatalf("unexpected result %q %v", ret, err)
+	}
+	msg := <-sub.C
+	if msg.Channel != "countdown" || msg.TaskNo != 42 || string(msg.Data) != `{"order_id":"A001"}` {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+
+	// 非 JSON 请求体作为字符串推送
+	if _, err = p.Request(ctx, &Payload{Path: "countdown", Template: "done {{.TaskNo}}", ContentType: ContentTypeRaw}); err != nil {
+		t.Fatal(err)
+	}
+	if msg = <-sub.C; string(msg.Data) != `"done 42"` {
+		t.Fatalf("unexpected data %s", msg.Data)
+	}
+}
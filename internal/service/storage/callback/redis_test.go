@@ -0,0 +1,78 @@
+package callback
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/x-thooh/delay/pkg/trace"
+)
+
+func TestRedisRequest(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	r := NewRedis(setLogger(), &Config{Redis: &RedisConfig{MaxLen: 100}})
+	defer func() {
+		_ = r.Close(context.Background())
+	}()
+
+	ctx := NewContext(trace.Set(context.Background(), "t-1"), &Meta{TaskNo: 42, Tenant: "order"})
+	ret, err := r.Request(ctx, &Payload{
+		Schema:  "REDIS",
+		Url:     s.Addr(),
+		Path:    "delay:orders",
+		Data:    map[string]any{"order_id": "A001"},
+		Headers: map[string]string{"x-custom": "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret != "SUCCESS" {
+		t.Fatalf("unexpected result %q", ret)
+	}
+	entries, err := s.Stream("delay:orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("unexpected entries %d", len(entries))
+	}
+	values := make(map[string]string)
+	for i := 0; i+1 < len(entries[0].Values); i += 2 {
+		values[entries[0].Values[i]] = entries[0].Values[i+1]
+	}
+	want := map[string]string{
+		"x-custom":     "1",
+		"task_no":      "42",
+		"tenant":       "order",
+		"trace_id":     "t-1",
+		"content_type": "application/json",
+		"payload":      `{"order_id":"A001"}`,
+	}
+	for k, v := range want {
+		if values[k] != v {
+			t.Fatalf("field %s = %q, want %q", k, values[k], v)
+		}
+	}
+
+	// LPUSH 写入请求体
+	if _, err = r.Request(ctx, &Payload{
+		Url:    "redis://" + s.Addr() + "/0",
+		Path:   "delay:jobs",
+		Method: "lpush",
+		Data:   map[string]any{"job": 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.List("delay:jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0] != `{"job":1}` {
+		t.Fatalf("unexpected list %v", list)
+	}
+
+	if _, err = r.Request(ctx, &Payload{Url: s.Addr(), Path: "k", Method: "SET"}); err == nil {
+		t.Fatal("expected unsupported method error")
+	}
+}
@@ -0,0 +1,194 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/x-thooh/delay/internal/service/push"
+	"github.com/x-thooh/delay/pkg/log"
+)
+
+// Adapter 具名回调实例
+type Adapter struct {
+	// 任务 schema 中使用的名称，同名时覆盖内置实例
+	Name string `yaml:"name"`
+	// 回调类型：FMT、HTTP、HTTPS、GRPC、KAFKA、REDIS、AMQP、EXEC、PUSH
+	Type string `yaml:"type"`
+	// 实例配置，未设置的部分沿用 callback 全局配置
+	Config *Config `yaml:"config"`
+}
+
+type options struct {
+	lg  log.Logger
+	hub *push.Hub
+}
+
+type Option func(*options)
+
+func WithLogger(lg log.Logger) Option {
+	return func(o *options) {
+		o.lg = lg
+	}
+}
+
+// WithHub PUSH 回调使用的订阅中心，未设置时 PUSH 实例不可用
+func WithHub(hub *push.Hub) Option {
+	return func(o *options) {
+		o.hub = hub
+	}
+}
+
+type factory func(o *options, cfg *Config) (ICallback, error)
+
+// factories 内置回调类型，类型名同时作为默认实例名
+var factories = map[string]factory{
+	"FMT": func(o *options, _ *Config) (ICallback, error) {
+		return NewFmt(o.lg), nil
+	},
+	"HTTP": func(o *options, cfg *Config) (ICallback, error) {
+		return NewHttp(o.lg, cfg), nil
+	},
+	"HTTPS": func(o *options, cfg *Config) (ICallback, error) {
+		return NewHttp(o.lg, cfg), nil
+	},
+	"GRPC": func(o *options, cfg *Config) (ICallback, error) {
+		return NewGRPC(o.lg, cfg)
+	},
+	"KAFKA": func(o *options, cfg *Config) (ICallback, error) {
+		return NewKafka(o.lg, cfg), nil
+	},
+	"REDIS": func(o *options, cfg *Config) (ICallback, error) {
+		return NewRedis(o.lg, cfg), nil
+	},
+	"AMQP": func(o *options, cfg *Config) (ICallback, error) {
+		return NewAmqp(o.lg, cfg), nil
+	},
+	"EXEC": func(o *options, cfg *Config) (ICallback, error) {
+		return NewExec(o.lg, cfg), nil
+	},
+	"PUSH": func(o *options, _ *Config) (ICallback, error) {
+		if o.hub == nil {
+			return nil, nil
+		}
+		return NewPush(o.lg, o.hub), nil
+	},
+}
+
+// Registry 回调实例，由 Storage 持有，不同 Storage 之间互不影响
+type Registry struct {
+	adapters map[string]ICallback
+}
+
+// NewRegistry 按配置创建回调实例：每个内置类型一个同名实例，再加上 cfg.Adapters 中的具名实例
+func NewRegistry(cfg *Config, opts ...Option) (*Registry, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var adapters []*Adapter
+	seen := make(map[string]struct{})
+	if cfg != nil {
+		for _, a := range cfg.Adapters {
+			name := strings.ToUpper(a.Name)
+			if name == "" {
+				return nil, errors.New("callback adapter name is required")
+			}
+			if _, ok := seen[name]; ok {
+				return nil, fmt.Errorf("duplicate callback adapter %q", a.Name)
+			}
+			seen[name] = struct{}{}
+			adapters = append(adapters, a)
+		}
+	}
+	for typ := range factories {
+		if _, ok := seen[typ]; !ok {
+			adapters = append(adapters, &Adapter{Name: typ, Type: typ})
+		}
+	}
+
+	r := &Registry{adapters: make(map[string]ICallback)}
+	for _, a := range adapters {
+		f, ok := factories[strings.ToUpper(a.Type)]
+		if !ok {
+			_ = r.Close(context.Background())
+			return nil, fmt.Errorf("callback adapter %q: unknown type %q", a.Name, a.Type)
+		}
+		c, err := f(o, cfg.merge(a.Config))
+		if err != nil {
+			_ = r.Close(context.Background())
+			return nil, fmt.Errorf("callback adapter %q: %w", a.Name, err)
+		}
+		if c != nil {
+			r.adapters[strings.ToUpper(a.Name)] = c
+		}
+	}
+	return r, nil
+}
+
+// Get 按任务 schema 返回回调实例，名称不区分大小写
+func (r *Registry) Get(name string) (ICallback, bool) {
+	c, ok := r.adapters[strings.ToUpper(name)]
+	return c, ok
+}
+
+// Names 返回所有实例名
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.adapters))
+	for name := range r.adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close 关闭所有实例
+func (r *Registry) Close(ctx context.Context) error {
+	var errs []error
+	for name, c := range r.adapters {
+		if err := c.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("close callback adapter %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// merge 以实例配置 o 中设置的部分覆盖全局配置
+func (c *Config) merge(o *Config) *Config {
+	if c == nil {
+		return o
+	}
+	if o == nil {
+		return c
+	}
+	m := *c
+	m.Adapters = nil
+	if o.Targets != nil {
+		m.Targets = o.Targets
+	}
+	if o.Signing != nil {
+		m.Signing = o.Signing
+	}
+	if o.Http != nil {
+		m.Http = o.Http
+	}
+	if o.Grpc != nil {
+		m.Grpc = o.Grpc
+	}
+	if o.Kafka != nil {
+		m.Kafka = o.Kafka
+	}
+	if o.Redis != nil {
+		m.Redis = o.Redis
+	}
+	if o.Amqp != nil {
+		m.Amqp = o.Amqp
+	}
+	if o.Exec != nil {
+		m.Exec = o.Exec
+	}
+	return &m
+}
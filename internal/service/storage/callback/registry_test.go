@@ -0,0 +1,74 @@
+package callback
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/x-thooh/delay/internal/service/push"
+)
+
+func TestRegistry(t *testing.T) {
+	cfg := &Config{
+		Redis: &RedisConfig{MaxLen: 100},
+		Adapters: []*Adapter{
+			{Name: "orders", Type: "kafka", Config: &Config{Kafka: &KafkaConfig{ClientID: "orders"}}},
+			{Name: "REDIS", Type: "REDIS", Config: &Config{Redis: &RedisConfig{PoolSize: 4}}},
+		},
+	}
+	r, err := NewRegistry(cfg, WithLogger(setLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = r.Close(context.Background())
+	}()
+
+	c, ok := r.Get("Orders")
+	if !ok {
+		t.Fatal("expected orders adapter")
+	}
+	if k := c.(*Kafka); k.cfg.Kafka.ClientID != "orders" || k.cfg.Redis.MaxLen != 100 {
+		t.Fatalf("unexpected orders config %+v", k.cfg)
+	}
+	c, _ = r.Get("REDIS")
+	if rc := c.(*Redis).cfg.Redis; rc.PoolSize != 4 || rc.MaxLen != 0 {
+		t.Fatalf("unexpected redis config %+v", rc)
+	}
+	c, _ = r.Get("kafka")
+	if k := c.(*Kafka); k.cfg.Kafka != nil {
+		t.Fatalf("unexpected default kafka config %+v", k.cfg.Kafka)
+	}
+	// 未注入订阅中心时没有 PUSH 实例
+	if _, ok = r.Get("PUSH"); ok || slices.Contains(r.Names(), "PUSH") {
+		t.Fatal("unexpected push adapter")
+	}
+
+	// 两个注册表的实例互不共享
+	r2, err := NewRegistry(nil, WithHub(push.NewHub()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = r2.Close(context.Background())
+	}()
+	c2, _ := r2.Get("HTTP")
+	if c1, _ := r.Get("HTTP"); c1 == c2 {
+		t.Fatal("registries share adapters")
+	}
+	if _, ok = r2.Get("PUSH"); !ok {
+		t.Fatal("expected push adapter")
+	}
+}
+
+func TestRegistryInvalid(t *testing.T) {
+	for _, adapters := range [][]*Adapter{
+		{{Name: "", Type: "HTTP"}},
+		{{Name: "a", Type: "SMTP"}},
+		{{Name: "a", Type: "HTTP"}, {Name: "A", Type: "FMT"}},
+	} {
+		if _, err := NewRegistry(&Config{Adapters: adapters}); err == nil {
+			t.Fatalf("expected error for %+v", adapters[0])
+		}
+	}
+}
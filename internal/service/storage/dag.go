@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/x-thooh/delay/pkg/trace"
+)
+
+// 父任务失败或取消时子任务的处理方式
+const (
+	// ParentFailureCancel 取消子任务（默认）
+	ParentFailureCancel = "cancel"
+	// ParentFailureFail 子任务标记为失败
+	ParentFailureFail = "fail"
+	// ParentFailureRun 父任务结束即视为满足，继续调度子任务
+	ParentFailureRun = "run"
+)
+
+// addChild 插入等待父任务的子任务（status=5），父任务须属于同一租户且不是定时任务
+func (d *Storage) addChild(ctx context.Context, task *TaskEntity, parents []int64) error {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// 锁定父任务，避免父任务在依赖写入前结束而漏掉调度
+	query, args, err := sqlx.In(`
+        SELECT task_no, COALESCE(cron_expr, '') AS cron_expr FROM task_queue
+        WHERE task_no IN (?) AND tenant=?
+        FOR UPDATE
+    `, parents, task.Tenant)
+	if err != nil {
+		return err
+	}
+	var found []struct {
+		TaskNo   int64  `db:"task_no"`
+		CronExpr string `db:"cron_expr"`
+	}
+	if err = tx.SelectContext(ctx, &found, tx.Rebind(query), args...); err != nil {
+		return err
+	}
+	exists := make(map[int64]struct{}, len(found))
+	for _, p := range found {
+		// 定时任务成功后保持执行中，不会结束，子任务将一直等待
+		if p.CronExpr != "" {
+			return fmt.Errorf("%w: parent task %d is a cron task", ErrInvalid, p.TaskNo)
+		}
+		exists[p.TaskNo] = struct{}{}
+	}
+	for _, p := range parents {
+		if _, ok := exists[p]; !ok {
+			return fmt.Errorf("%w: parent task %d not found", ErrInvalid, p)
+		}
+	}
+
+	task.Status = 5
+	take, err := d.insert(ctx, tx, task)
+	if err != nil {
+		return err
+	}
+	for tn := range exists {
+		if _, err = tx.ExecContext(ctx, `
+            INSERT INTO task_dependency (child_task_no, parent_task_no, created_at)
+            VALUES (?, ?, ?)
+        `, task.TaskNo, tn, task.CreatedAt); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	take()
+	// 父任务可能已经结束，处理失败时由定时补偿
+	if err = d.settle(ctx, task.TaskNo); err != nil {
+		d.collect(ctx, fmt.Errorf("settle task %d: %w", task.TaskNo, err))
+	}
+	return nil
+}
+
+// finish 任务结束后删除外置的 data 并处理其子任务，删除失败仅记录，不影响子任务调度
+func (d *Storage) finish(ctx context.Context, taskNo int64) error {
+	if err := d.purge(ctx, taskNo); err != nil {
+		d.collect(ctx, fmt.Errorf("purge task %d: %w", taskNo, err))
+	}
+	return d.resolve(ctx, taskNo)
+}
+
+// resolve 父任务结束后处理其等待中的子任务
+func (d *Storage) resolve(ctx context.Context, parent int64) error {
+	var children []int64
+	if err := d.db.SelectContext(ctx, &children, `
+        SELECT child_task_no FROM task_dependency
+        WHERE parent_task_no=?
+    `, parent); err != nil {
+		return err
+	}
+	var errs []error
+	for _, child := range children {
+		if err := d.settle(ctx, child); err != nil {
+			errs = append(errs, fmt.Errorf("settle task %d: %w", child, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// settle 父任务全部成功时调度子任务；存在失败或取消的父任务时按 on_parent_failure 处理
+func (d *Storage) settle(ctx context.Context, taskNo int64) error {
+	task := &TaskEntity{}
+	err := d.db.GetContext(ctx, task, `SELECT * FROM task_queue WHERE task_no=? AND status=5`, taskNo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var statuses []int
+	if err = d.db.SelectContext(ctx, &statuses, `
+        SELECT p.status FROM task_dependency d
+        JOIN task_queue p ON p.task_no=d.parent_task_no
+        WHERE d.child_task_no=?
+    `, taskNo); err != nil {
+		return err
+	}
+
+	policy := ParentFailureCancel
+	if task.Extra != nil && task.Extra.OnParentFailure != "" {
+		policy = task.Extra.OnParentFailure
+	}
+	ready := true
+	for _, status := range statuses {
+		switch status {
+		case 2:
+		case 3, 4:
+			if policy != ParentFailureRun {
+				return d.abandon(ctx, task, policy)
+			}
+		default:
+			ready = false
+		}
+	}
+	if !ready {
+		return nil
+	}
+	return d.release(ctx, task)
+}
+
+// release 父任务满足后按子任务自身的延迟时间调度
+func (d *Storage) release(ctx context.Context, task *TaskEntity) error {
+	now := time.Now()
+	task.NextRunAt = now.Add(time.Duration(task.DelayTime) * time.Second)
+	task.RunTimeoutAt = task.NextRunAt.Add(time.Duration(task.Timeout) * time.Second)
+	d.lg.Info(ctx, "Release Task", "task_no", task.TaskNo, "delay_time", task.DelayTime)
+	if time.Duration(task.DelayTime)*time.Second > d.cfg.FastPathTime {
+		_, err := d.db.ExecContext(ctx, `
+            UPDATE task_queue
+            SET status=0, next_run_at=?, run_timeout_at=?, updated_at=?
+            WHERE task_no=? AND status=5
+        `, task.NextRunAt, task.RunTimeoutAt, now, task.TaskNo)
+		return err
+	}
+
+	ret, err := d.db.ExecContext(ctx, `
+        UPDATE task_queue
+        SET status=1, fail_count=0, next_run_at=?, run_timeout_at=?, locked_by=?, updated_at=?
+        WHERE task_no=? AND status=5
+    `, task.NextRunAt, task.RunTimeoutAt, d.cfg.Node, now, task.TaskNo)
+	if err != nil {
+		return err
+	}
+	if n, err := ret.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	task.Status, task.FailCount, task.LockedBy = 1, 0, int64(d.cfg.Node)
+	return d.Submit(trace.Set(context.Background(), task.TraceId()), task, -1)
+}
+
+// abandon 父任务失败或取消时取消（或标记失败）子任务，并继续处理其后代
+func (d *Storage) abandon(ctx context.Context, task *TaskEntity, policy string) error {
+	status := 4
+	if policy == ParentFailureFail {
+		status = 3
+	}
+	d.lg.Info(ctx, "Abandon Task", "task_no", task.TaskNo, "status", status)
+	task.WithFailMsg(&FailMsg{Err: "parent task failed or canceled"})
+	ret, err := d.db.ExecContext(ctx, `
+        UPDATE task_queue
+        SET status=?, fail_msgs=?, updated_at=?
+        WHERE task_no=? AND status=5
+    `, status, task.FailMsgs, time.Now(), task.TaskNo)
+	if err != nil {
+		return err
+	}
+	if n, err := ret.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	return d.finish(ctx, task.TaskNo)
+}
+
+// FetchWaitingTasks 查询父任务均已结束但仍在等待的子任务，用于补偿父任务结束后未及时处理的情况
+func (d *Storage) FetchWaitingTasks(ctx context.Context, maxCount int) ([]int64, error) {
+	slots := d.owned()
+	if len(slots) == 0 {
+		return nil, nil
+	}
+	query, args, err := sqlx.In(`
+        SELECT t.task_no FROM task_queue t
+        WHERE t.status=5 AND t.slot IN (?) AND NOT EXISTS (
+            SELECT 1 FROM task_dependency d
+            JOIN task_queue p ON p.task_no=d.parent_task_no
+            WHERE d.child_task_no=t.task_no AND p.status IN (0,1,5)
+        )
+        LIMIT ?
+    `, slots, maxCount)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []int64
+	err = d.db.SelectContext(ctx, &tasks, d.db.Rebind(query), args...)
+	return tasks, err
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/x-thooh/delay/internal/service/storage/leader"
+)
+
+// SetElector 设置选主，需在 Start 前调用；未设置时每个节点都执行单例任务
+func (d *Storage) SetElector(e leader.Elector) {
+	d.elector = e
+}
+
+// Instance 实例标识，用于节点注册及选主
+func (d *Storage) Instance() string {
+	return d.instance
+}
+
+// IsLeader 本节点是否为主节点
+func (d *Storage) IsLeader() bool {
+	return d.elector == nil || d.elector.IsLeader()
+}
+
+// ScheduleSingleton 按间隔执行集群内只需一个节点执行的任务，仅主节点执行
+func (d *Storage) ScheduleSingleton(interval time.Duration, f func(ctx context.Context)) error {
+	return d.ScheduleFunc(interval, func(ctx context.Context) {
+		if d.IsLeader() {
+			f(ctx)
+		}
+	})
+}
+
+// elect 参与选举，Stop 时退出并释放租约
+func (d *Storage) elect() {
+	if d.elector == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	d.stopElect = func(wait context.Context) {
+		cancel()
+		select {
+		case <-done:
+		case <-wait.Done():
+		}
+	}
+	go func() {
+		defer close(done)
+		d.elector.Run(ctx)
+	}()
+}
+
+// recoverTimeout 将超过执行超时时间仍未结束的任务放回待处理，如执行节点的时间轮丢失了任务，
+// 定时任务常驻执行节点，不做处理
+func (d *Storage) recoverTimeout(ctx context.Context) error {
+	now := time.Now()
+	res, err := d.db.ExecContext(ctx, `
+        UPDATE task_queue
+        SET status=0, fail_count=fail_count-1, updated_at=?
+        WHERE status=1 AND run_timeout_at <= ? AND (cron_expr IS NULL OR cron_expr='')
+        LIMIT ?
+    `, now, now.Add(-d.cfg.timeoutGrace()), d.cfg.timeoutLimit())
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		d.lg.Info(ctx, "Recover Timeout Tasks", "rows", n)
+	}
+	return nil
+}
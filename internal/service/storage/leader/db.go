@@ -0,0 +1,95 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/x-thooh/delay/pkg/log"
+)
+
+// DB 基于 leader_lease 表的租约，过期时间以数据库时间为准
+type DB struct {
+	db  *sqlx.DB
+	lg  log.Logger
+	cfg *Config
+	id  string
+
+	// 本地判定的租约到期时间（UnixNano），续约失败时到期即失去领导权
+	until atomic.Int64
+}
+
+func NewDB(cfg *Config, db *sqlx.DB, lg log.Logger, id string) *DB {
+	return &DB{db: db, lg: lg, cfg: cfg, id: id}
+}
+
+func (e *DB) IsLeader() bool {
+	return time.Now().UnixNano() < e.until.Load()
+}
+
+func (e *DB) Run(ctx context.Context) {
+	ttl := e.cfg.ttl()
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		leader := e.IsLeader()
+		if err := e.acquire(ctx); err != nil && ctx.Err() == nil {
+			e.lg.Error(ctx, "acquire leader lease", "name", e.cfg.name(), "err", err)
+		}
+		if now := e.IsLeader(); now != leader {
+			e.lg.Info(ctx, "leader changed", "name", e.cfg.name(), "id", e.id, "leader", now)
+		}
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// acquire 租约不存在、已过期或由本节点持有时获取（续约）
+func (e *DB) acquire(ctx context.Context) error {
+	ttl := e.cfg.ttl()
+	start := time.Now()
+	_, err := e.db.ExecContext(ctx, `
+        INSERT IGNORE INTO leader_lease (name, holder, expires_at, updated_at)
+        VALUES (?, ?, NOW(6) + INTERVAL ? MICROSECOND, NOW(6))
+    `, e.cfg.name(), e.id, ttl.Microseconds())
+	if err != nil {
+		return err
+	}
+	if _, err = e.db.ExecContext(ctx, `
+        UPDATE leader_lease SET holder=?, expires_at=NOW(6) + INTERVAL ? MICROSECOND, updated_at=NOW(6)
+        WHERE name=? AND (holder=? OR expires_at < NOW(6))
+    `, e.id, ttl.Microseconds(), e.cfg.name(), e.id); err != nil {
+		return err
+	}
+	var holder string
+	if err = e.db.GetContext(ctx, &holder, `SELECT holder FROM leader_lease WHERE name=?`, e.cfg.name()); err != nil {
+		return err
+	}
+	if holder != e.id {
+		e.until.Store(0)
+		return nil
+	}
+	// 从发起请求时计算，本地判定的到期时间早于数据库中的到期时间
+	e.until.Store(start.Add(ttl).UnixNano())
+	return nil
+}
+
+// release 主动过期租约，其他节点下次续约时即可接替
+func (e *DB) release() {
+	if !e.IsLeader() {
+		return
+	}
+	e.until.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if _, err := e.db.ExecContext(ctx, `
+        UPDATE leader_lease SET expires_at=NOW(6) WHERE name=? AND holder=?
+    `, e.cfg.name(), e.id); err != nil {
+		e.lg.Error(ctx, "release leader lease", "name", e.cfg.name(), "err", err)
+	}
+}
@@ -0,0 +1,66 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/x-thooh/delay/pkg/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Kube 基于 coordination.k8s.io Lease 的选主
+type Kube struct {
+	cs        kubernetes.Interface
+	lg        log.Logger
+	cfg       *Config
+	namespace string
+	id        string
+
+	leader atomic.Bool
+}
+
+func NewKube(cfg *Config, cs kubernetes.Interface, lg log.Logger, namespace, id string) *Kube {
+	return &Kube{cs: cs, lg: lg, cfg: cfg, namespace: namespace, id: id}
+}
+
+func (e *Kube) IsLeader() bool {
+	return e.leader.Load()
+}
+
+func (e *Kube) Run(ctx context.Context) {
+	ttl := e.cfg.ttl()
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Namespace: e.namespace, Name: e.cfg.name()},
+		Client:     e.cs.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: e.id},
+	}
+	// 选举结束（如 API 不可用导致续约失败）后重新参与，直到 ctx 结束
+	for ctx.Err() == nil {
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   ttl,
+			RenewDeadline:   ttl * 2 / 3,
+			RetryPeriod:     ttl / 6,
+			ReleaseOnCancel: true,
+			Name:            e.cfg.name(),
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(context.Context) {
+					e.leader.Store(true)
+					e.lg.Info(ctx, "leader changed", "name", e.cfg.name(), "id", e.id, "leader", true)
+				},
+				OnStoppedLeading: func() {
+					e.leader.Store(false)
+					e.lg.Info(ctx, "leader changed", "name", e.cfg.name(), "id", e.id, "leader", false)
+				},
+			},
+		})
+		select {
+		case <-ctx.Done():
+		case <-time.After(ttl / 6):
+		}
+	}
+}
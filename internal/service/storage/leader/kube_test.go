@@ -0,0 +1,64 @@
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/x-thooh/delay/pkg/log"
+	"github.com/x-thooh/delay/pkg/log/xslog"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestKube(t *testing.T) {
+	lg, _, _ := xslog.New(&log.Config{Model: "std", Level: "error", Format: "text"})
+	cs := fake.NewClientset()
+	cfg := &Config{Type: TypeKubernetes, TTL: 2 * time.Second}
+	a := NewKube(cfg, cs, lg, "default", "delay-0")
+	b := NewKube(cfg, cs, lg, "default", "delay-1")
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	doneA := make(chan struct{})
+	go func() {
+		defer close(doneA)
+		a.Run(ctxA)
+	}()
+	waitFor(t, a.IsLeader)
+
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	go b.Run(ctxB)
+	time.Sleep(3 * time.Second)
+	if b.IsLeader() {
+		t.Fatalf("only one leader expected, a=%v", a.IsLeader())
+	}
+
+	// 主节点退出时释放租约，其他节点接替
+	cancelA()
+	<-doneA
+	if a.IsLeader() {
+		t.Fatal("leader should step down after cancel")
+	}
+	waitFor(t, b.IsLeader)
+}
+
+func TestResolve(t *testing.T) {
+	var c *Config
+	if c.Resolve(true, true) != TypeKubernetes || c.Resolve(false, true) != TypeDB || c.Resolve(false, false) != TypeNone {
+		t.Fatal("unexpected default type")
+	}
+	if (&Config{Type: TypeDB}).Resolve(true, false) != TypeDB {
+		t.Fatal("configured type should win")
+	}
+}
@@ -0,0 +1,58 @@
+// Package leader 选主：定时清理、超时恢复等集群内只需一个节点执行的任务由主节点执行。
+package leader
+
+import (
+	"context"
+	"time"
+)
+
+// 选主方式
+const (
+	TypeNone       = "none"
+	TypeDB         = "db"
+	TypeKubernetes = "kubernetes"
+)
+
+type Config struct {
+	// 选主方式：db、kubernetes、none；为空时 Kubernetes 中为 kubernetes，开启节点注册时为 db，否则为 none
+	Type string `yaml:"type"`
+	// 租约名称，默认 delay-leader
+	Name string `yaml:"name"`
+	// 租约时长，主节点失联超过该时间后由其他节点接替，默认 15s，kubernetes 按秒取整
+	TTL time.Duration `yaml:"ttl"`
+}
+
+func (c *Config) name() string {
+	if c == nil || c.Name == "" {
+		return "delay-leader"
+	}
+	return c.Name
+}
+
+func (c *Config) ttl() time.Duration {
+	if c == nil || c.TTL <= 0 {
+		return 15 * time.Second
+	}
+	return c.TTL
+}
+
+// Resolve 按运行环境确定选主方式
+func (c *Config) Resolve(inPod, cluster bool) string {
+	if c != nil && c.Type != "" {
+		return c.Type
+	}
+	switch {
+	case inPod:
+		return TypeKubernetes
+	case cluster:
+		return TypeDB
+	}
+	return TypeNone
+}
+
+type Elector interface {
+	// Run 参与选举直到 ctx 结束，结束时释放租约
+	Run(ctx context.Context)
+	// IsLeader 当前是否持有租约
+	IsLeader() bool
+}
@@ -0,0 +1,106 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/x-thooh/delay/internal/service/storage/callback"
+	"golang.org/x/time/rate"
+)
+
+const (
+	KeyByHost = "host"
+	KeyByUrl  = "url"
+)
+
+type Config struct {
+	Enable bool `yaml:"enable"`
+	// 限流维度：host 或 url
+	KeyBy string `yaml:"key_by"`
+	// 默认每秒令牌数，0 表示未配置的目标不限流
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+	// 单独配置的目标
+	Targets []*Target `yaml:"targets"`
+}
+
+type Target struct {
+	Key   string  `yaml:"key"`
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// Limiter 按回调目标划分的令牌桶
+type Limiter struct {
+	cfg     *Config
+	targets map[string]*Target
+
+	mu      sync.Mutex
+	buckets map[string]*rate.Limiter
+}
+
+func New(cfg *Config) *Limiter {
+	if cfg == nil || !cfg.Enable {
+		return nil
+	}
+	l := &Limiter{
+		cfg:     cfg,
+		targets: make(map[string]*Target, len(cfg.Targets)),
+		buckets: make(map[string]*rate.Limiter),
+	}
+	for _, t := range cfg.Targets {
+		l.targets[t.Key] = t
+	}
+	return l
+}
+
+// Reserve 尝试获取一个令牌，返回需要推迟的时间，0 表示可立即执行
+func (l *Limiter) Reserve(payload *callback.Payload) time.Duration {
+	if l == nil {
+		return 0
+	}
+	b := l.bucket(l.Key(payload))
+	if b == nil {
+		return 0
+	}
+	now := time.Now()
+	r := b.ReserveN(now, 1)
+	if !r.OK() {
+		return time.Second
+	}
+	wait := r.DelayFrom(now)
+	if wait > 0 {
+		// 归还令牌，任务推迟后重新获取
+		r.CancelAt(now)
+	}
+	return wait
+}
+
+func (l *Limiter) Key(payload *callback.Payload) string {
+	if l.cfg.KeyBy == KeyByUrl {
+		return payload.Url + payload.Path
+	}
+	return callback.Host(payload.Url)
+}
+
+func (l *Limiter) bucket(key string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[key]; ok {
+		return b
+	}
+	r, burst := l.cfg.Rate, l.cfg.Burst
+	if t, ok := l.targets[key]; ok {
+		r, burst = t.Rate, t.Burst
+	}
+	if r <= 0 {
+		l.buckets[key] = nil
+		return nil
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	b := rate.NewLimiter(rate.Limit(r), burst)
+	l.buckets[key] = b
+	return b
+}
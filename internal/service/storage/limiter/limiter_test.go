@@ -0,0 +1,34 @@
+package limiter
+
+import (
+	"testing"
+
+	"github.com/x-thooh/delay/internal/service/storage/callback"
+)
+
+func TestReserve(t *testing.T) {
+	l := New(&Config{
+		Enable: true,
+		KeyBy:  KeyByHost,
+		Targets: []*Target{
+			{Key: "127.0.0.1:8081", Rate: 1, Burst: 2},
+		},
+	})
+	p := &callback.Payload{Schema: "http", Url: "127.0.0.1:8081", Path: "/example/valid"}
+	for i := 0; i < 2; i++ {
+		if wait := l.Reserve(p); wait != 0 {
+			t.Fatalf("reserve %d: wait %v", i, wait)
+		}
+	}
+	if wait := l.Reserve(p); wait <= 0 {
+		t.Fatalf("expected wait after burst exhausted, got %v", wait)
+	}
+
+	// 未配置的目标不限流
+	other := &callback.Payload{Schema: "http", Url: "127.0.0.1:9090"}
+	for i := 0; i < 10; i++ {
+		if wait := l.Reserve(other); wait != 0 {
+			t.Fatalf("unexpected wait %v", wait)
+		}
+	}
+}
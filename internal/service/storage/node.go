@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/x-thooh/delay/pkg/timingwheel/bucket"
+)
+
+// maxNode locked_by 为 TINYINT，节点编号不超过 127
+const maxNode = 127
+
+var ErrNodeTaken = errors.New("node is taken by another instance")
+
+// ClusterConfig 基于数据库的节点注册：节点定时向 node_registry 心跳，超时未心跳的节点被剔除，
+// 存活节点用于任务分片，适用于非 Kubernetes 部署
+type ClusterConfig struct {
+	Enable bool `yaml:"enable"`
+	// 心跳间隔，默认 5s
+	Heartbeat time.Duration `yaml:"heartbeat"`
+	// 超过该时间未心跳的节点视为下线，默认 3 个心跳间隔
+	TTL time.Duration `yaml:"ttl"`
+	// 配置的 node 已被存活节点占用时，自动使用最小的空闲编号
+	AutoNode bool `yaml:"auto_node"`
+}
+
+func (c *ClusterConfig) enabled() bool {
+	return c != nil && c.Enable
+}
+
+func (c *ClusterConfig) heartbeat() time.Duration {
+	if c.Heartbeat <= 0 {
+		return 5 * time.Second
+	}
+	return c.Heartbeat
+}
+
+func (c *ClusterConfig) ttl() time.Duration {
+	if c.TTL <= 0 {
+		return 3 * c.heartbeat()
+	}
+	return c.TTL
+}
+
+// instanceId 实例标识，区分重启前后占用同一编号的进程
+func instanceId() string {
+	host, _ := os.Hostname()
+	return host + ":" + strconv.Itoa(os.Getpid()) + ":" + uuid.NewString()[:8]
+}
+
+// join 注册节点，返回占用的节点编号
+func (d *Storage) join(ctx context.Context, node int) (int, error) {
+	ok, err := d.claim(ctx, node)
+	if err != nil || ok {
+		return node, err
+	}
+	if !d.cfg.Cluster.AutoNode {
+		return 0, fmt.Errorf("%w: %d", ErrNodeTaken, node)
+	}
+	live, err := d.LiveNodes(ctx)
+	if err != nil {
+		return 0, err
+	}
+	used := make(map[int]struct{}, len(live))
+	for _, n := range live {
+		used[n] = struct{}{}
+	}
+	for n := 0; n <= maxNode; n++ {
+		if _, ok = used[n]; ok {
+			continue
+		}
+		// 并发注册时可能被其他实例抢先，继续尝试下一个
+		if ok, err = d.claim(ctx, n); err != nil || ok {
+			return n, err
+		}
+	}
+	return 0, fmt.Errorf("no free node in [0, %d]", maxNode)
+}
+
+// claim 占用节点编号：编号未注册、已下线或本实例占用时成功
+func (d *Storage) claim(ctx context.Context, node int) (bool, error) {
+	res, err := d.db.ExecContext(ctx, `
+        INSERT IGNORE INTO node_registry (node_id, instance, heartbeat_at, created_at)
+        VALUES (?, ?, NOW(6), NOW(6))
+    `, node, d.instance)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return true, nil
+	}
+	res, err = d.db.ExecContext(ctx, `
+        UPDATE node_registry SET instance=?, heartbeat_at=NOW(6), created_at=NOW(6)
+        WHERE node_id=? AND (instance=? OR heartbeat_at < NOW(6) - INTERVAL ? MICROSECOND)
+    `, d.instance, node, d.instance, d.cfg.Cluster.ttl().Microseconds())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// heartbeat 续期本节点，已被其他实例占用时返回 ErrNodeTaken
+func (d *Storage) heartbeat(ctx context.Context) error {
+	res, err := d.db.ExecContext(ctx, `
+        UPDATE node_registry SET heartbeat_at=NOW(6)
+        WHERE node_id=? AND instance=?
+    `, d.cfg.Node, d.instance)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// 已被剔除（如长时间停顿），编号仍空闲时重新占用
+		ok, err := d.claim(ctx, d.cfg.Node)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: %d", ErrNodeTaken, d.cfg.Node)
+		}
+	}
+	return nil
+}
+
+// evict 删除下线节点的注册记录，由主节点执行
+func (d *Storage) evict(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, `
+        DELETE FROM node_registry WHERE heartbeat_at < NOW(6) - INTERVAL ? MICROSECOND
+    `, d.cfg.Cluster.ttl().Microseconds())
+	return err
+}
+
+// leave 注销本节点，其他节点下次刷新时接管其任务
+func (d *Storage) leave(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, `
+        DELETE FROM node_registry WHERE node_id=? AND instance=?
+    `, d.cfg.Node, d.instance)
+	return err
+}
+
+// LiveNodes 存活节点编号（升序），时间以数据库为准以避免节点间时钟偏差
+func (d *Storage) LiveNodes(ctx context.Context) ([]int, error) {
+	var ns []int
+	err := d.db.SelectContext(ctx, &ns, `
+        SELECT node_id FROM node_registry
+        WHERE heartbeat_at >= NOW(6) - INTERVAL ? MICROSECOND
+        ORDER BY node_id
+    `, d.cfg.Cluster.ttl().Microseconds())
+	return ns, err
+}
+
+// refreshNodes 心跳并按存活节点重新分片
+func (d *Storage) refreshNodes(ctx context.Context) error {
+	if err := d.heartbeat(ctx); err != nil {
+		if errors.Is(err, ErrNodeTaken) {
+			d.fence(ctx, err)
+		}
+		return err
+	}
+	ns, err := d.LiveNodes(ctx)
+	if err != nil {
+		return err
+	}
+	d.SetNodes(ns)
+	return nil
+}
+
+// fence 节点编号已被其他实例占用：立即释放槽位并停止本节点的定时任务，
+// 之后不再拉取、执行及新增任务，避免与对方重复执行或生成重复的任务编号
+func (d *Storage) fence(ctx context.Context, err error) {
+	if !d.taken.CompareAndSwap(nil, &err) {
+		return
+	}
+	if prev := d.slots.Load(); prev != nil {
+		d.slots.Store(&slotSet{nodes: prev.nodes})
+	}
+	d.crons.Range(func(k, v any) bool {
+		d.crons.Delete(k)
+		v.(*bucket.Timer).Stop()
+		return true
+	})
+	d.lg.Error(ctx, "node taken, stop working", "node", d.cfg.Node, "err", err)
+	select {
+	case d.fatal <- err:
+	default:
+	}
+}
+
+// Fatal 本节点无法继续工作（如节点编号被其他实例占用）时返回原因，由应用停止进程，
+// 重启后重新注册（auto_node 时使用空闲编号），Stop 同样返回该原因
+func (d *Storage) Fatal() <-chan error {
+	return d.fatal
+}
+
+// slotSet 存活节点（升序）及本节点负责的槽位
+type slotSet struct {
+	nodes []int
+	slots []int
+}
+
+// owns 槽位是否由本节点负责
+func (d *Storage) owns(slot int) bool {
+	_, ok := slices.BinarySearch(d.owned(), slot)
+	return ok
+}
+
+// clustered 是否多节点运行：开启节点注册，或存活节点不止本节点（如 StatefulSet）
+func (d *Storage) clustered() bool {
+	if d.cfg.Cluster.enabled() {
+		return true
+	}
+	s := d.slots.Load()
+	return s != nil && len(s.nodes) > 1
+}
+
+// owned 本节点负责的槽位
+func (d *Storage) owned() []int {
+	if s := d.slots.Load(); s != nil {
+		return s.slots
+	}
+	return nil
+}
+
+// reclaim 将执行节点已下线的执行中任务放回待处理，由拉取任务重新调度：
+// 启动时放回本节点上次运行遗留的任务，之后放回本节点槽位中由下线节点执行的任务。
+// 槽位在存活节点间移动时，原节点执行中的任务在本次执行结束（或超时恢复）后放回待处理，见 Success、Failure 及 Defer
+func (d *Storage) reclaim(ctx context.Context, set *slotSet, startup bool) error {
+	query, args := `
+        UPDATE task_queue
+        SET status=0, fail_count=fail_count-1, updated_at=?
+        WHERE status=1 AND locked_by=?
+    `, []any{time.Now(), d.cfg.Node}
+	if !startup {
+		if len(set.slots) == 0 {
+			return nil
+		}
+		var err error
+		if query, args, err = sqlx.In(`
+            UPDATE task_queue
+            SET status=0, fail_count=fail_count-1, updated_at=?
+            WHERE status=1 AND slot IN (?) AND locked_by NOT IN (?)
+        `, time.Now(), set.slots, set.nodes); err != nil {
+			return err
+		}
+	}
+	// 拉取时会重新累加失败次数
+	res, err := d.db.ExecContext(ctx, d.db.Rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		d.lg.Info(ctx, "Reclaim Tasks", "rows", n, "nodes", set.nodes, "startup", startup)
+	}
+	return nil
+}
@@ -17,6 +17,11 @@ type options struct {
 
 	// 回调
 	payload *callback.Payload
+
+	// 父任务，全部成功后才开始计算延迟
+	parents []int64
+	// 父任务失败或取消时的处理方式
+	parentFailure string
 }
 
 type Option func(*options)
@@ -50,3 +55,15 @@ func WithPayload(payload *callback.Payload) Option {
 		o.payload = payload
 	}
 }
+
+func WithParents(parents ...int64) Option {
+	return func(o *options) {
+		o.parents = parents
+	}
+}
+
+func WithParentFailure(policy string) Option {
+	return func(o *options) {
+		o.parentFailure = policy
+	}
+}
@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/x-thooh/delay/internal/service/storage/blob"
+	"github.com/x-thooh/delay/internal/service/storage/callback"
+	"github.com/x-thooh/delay/pkg/keyring"
+)
+
+// PayloadConfig 回调数据大小限制及外部存储
+type PayloadConfig struct {
+	// data 序列化后的最大字节数，默认 256KB
+	MaxSize int `yaml:"max_size"`
+	// 配置 blob 后，data 超过该字节数时存入 blob，数据库中仅保存引用，默认 4KB
+	InlineSize int          `yaml:"inline_size"`
+	Blob       *blob.Config `yaml:"blob"`
+	// 加密存储，blob 中的 data 同样加密
+	Encryption *EncryptionConfig `yaml:"encryption"`
+}
+
+// EncryptionConfig 回调数据信封加密
+type EncryptionConfig struct {
+	// 密钥环文件，为空时不加密
+	Keyring string `yaml:"keyring"`
+	// 将非主密钥加密（及未加密）的任务用主密钥重新加密的间隔，0 不执行
+	RotateInterval time.Duration `yaml:"rotate_interval"`
+	// 每次重新加密的任务数，默认 100
+	RotateLimit int `yaml:"rotate_limit"`
+}
+
+func (c *PayloadConfig) encryption() *EncryptionConfig {
+	if c == nil || c.Encryption == nil {
+		return &EncryptionConfig{}
+	}
+	return c.Encryption
+}
+
+func (c *PayloadConfig) maxSize() int {
+	if c == nil || c.MaxSize <= 0 {
+		return 256 << 10
+	}
+	return c.MaxSize
+}
+
+func (c *PayloadConfig) inlineSize() int {
+	if c == nil || c.InlineSize <= 0 {
+		return 4 << 10
+	}
+	return c.InlineSize
+}
+
+// checkSize 校验 data 序列化后的大小
+func (d *Storage) checkSize(p *callback.Payload) error {
+	if len(p.Data) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(p.Data)
+	if err != nil {
+		return fmt.Errorf("%w: data: %v", ErrInvalid, err)
+	}
+	if n := d.cfg.Payload.maxSize(); len(b) > n {
+		return fmt.Errorf("%w: data is %d bytes, exceeds limit of %d bytes", ErrInvalid, len(b), n)
+	}
+	return nil
+}
+
+// offload data 超过行内大小时存入 blob 并以 data_ref 引用，返回 blob key
+func (d *Storage) offload(ctx context.Context, taskNo int64, p *callback.Payload) (string, error) {
+	if d.blob == nil || len(p.Data) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(p.Data)
+	if err != nil || len(b) <= d.cfg.Payload.inlineSize() {
+		return "", err
+	}
+	key := blobKey(taskNo, ".json")
+	if d.keyring != nil {
+		var s *keyring.Sealed
+		if s, err = d.keyring.Seal(b, aad(taskNo)); err != nil {
+			return "", err
+		}
+		if b, err = json.Marshal(s); err != nil {
+			return "", err
+		}
+		key = blobKey(taskNo, sealedExt)
+	}
+	if err = d.blob.Put(ctx, key, b); err != nil {
+		return "", fmt.Errorf("store payload data: %w", err)
+	}
+	p.Data, p.DataRef = nil, key
+	return key, nil
+}
+
+// blobKey 任务 data 在 blob 中的 key，按任务编号分目录
+func blobKey(taskNo int64, ext string) string {
+	return fmt.Sprintf("%02x/%d%s", taskNo&0xff, taskNo, ext)
+}
+
+// discard 任务写入失败时删除已存入的 data
+func (d *Storage) discard(ref string) {
+	if ref != "" {
+		_ = d.blob.Delete(context.Background(), ref)
+	}
+}
+
+// purge 任务结束后删除存入 blob 的 data，key 由任务编号确定，无需读取 payload
+func (d *Storage) purge(ctx context.Context, taskNo int64) error {
+	if d.blob == nil {
+		return nil
+	}
+	return errors.Join(
+		d.blob.Delete(ctx, blobKey(taskNo, ".json")),
+		d.blob.Delete(ctx, blobKey(taskNo, sealedExt)),
+	)
+}
+
+// finished 任务已结束：成功、失败或取消
+func finished(status int) bool {
+	return status == 2 || status == 3 || status == 4
+}
+
+// sealedExt 加密存入 blob 的 data 扩展名
+const sealedExt = ".sealed"
+
+// aad 加密绑定任务编号，密文被复制到其他任务时无法解密
+func aad(taskNo int64) []byte {
+	return []byte(strconv.FormatInt(taskNo, 10))
+}
+
+// seal 返回用于写入数据库的任务，payload 整体加密
+func (d *Storage) seal(task *TaskEntity) (*TaskEntity, error) {
+	if d.keyring == nil {
+		return task, nil
+	}
+	b, err := json.Marshal(task.Payload)
+	if err != nil {
+		return nil, err
+	}
+	s, err := d.keyring.Seal(b, aad(task.TaskNo))
+	if err != nil {
+		return nil, fmt.Errorf("seal payload: %w", err)
+	}
+	row := *task
+	row.Payload = &callback.Payload{Sealed: s}
+	return &row, nil
+}
+
+// open 解密 payload
+func (d *Storage) open(taskNo int64, p *callback.Payload) error {
+	if d.keyring == nil {
+		return errors.New("open payload: keyring not configured")
+	}
+	b, err := d.keyring.Open(p.Sealed, aad(taskNo))
+	if err != nil {
+		return fmt.Errorf("open payload: %w", err)
+	}
+	*p = callback.Payload{}
+	return json.Unmarshal(b, p)
+}
+
+// load 解密任务的 payload 并读取存入 blob 的 data
+func (d *Storage) load(ctx context.Context, taskNo int64, p *callback.Payload) error {
+	if p == nil {
+		return nil
+	}
+	if p.Sealed != nil {
+		if err := d.open(taskNo, p); err != nil {
+			return err
+		}
+	}
+	if p.DataRef == "" || p.Data != nil {
+		return nil
+	}
+	if d.blob == nil {
+		return fmt.Errorf("load payload data %s: blob store not configured", p.DataRef)
+	}
+	b, err := d.blob.Get(ctx, p.DataRef)
+	if err != nil {
+		return fmt.Errorf("load payload data: %w", err)
+	}
+	if strings.HasSuffix(p.DataRef, sealedExt) {
+		s := &keyring.Sealed{}
+		if err = json.Unmarshal(b, s); err != nil {
+			return fmt.Errorf("load payload data: %w", err)
+		}
+		if d.keyring == nil {
+			return fmt.Errorf("load payload data %s: keyring not configured", p.DataRef)
+		}
+		if b, err = d.keyring.Open(s, aad(taskNo)); err != nil {
+			return fmt.Errorf("load payload data: %w", err)
+		}
+	}
+	return json.Unmarshal(b, &p.Data)
+}
+
+// rotate 用主密钥重新加密一批任务的 payload 及 blob 中的 data：已加密的仅重新加密数据密钥，未加密的 payload 整体加密；
+// 单个任务失败时记录后跳过，不阻塞后续任务
+func (d *Storage) rotate(ctx context.Context) error {
+	var rows []*struct {
+		Id      int64             `db:"id"`
+		TaskNo  int64             `db:"task_no"`
+		Payload *callback.Payload `db:"payload"`
+	}
+	slots := d.owned()
+	if len(slots) == 0 {
+		return nil
+	}
+	limit := d.cfg.Payload.encryption().rotateLimit()
+	query, args, err := sqlx.In(`
+        SELECT id, task_no, payload FROM task_queue
+        WHERE id > ? AND slot IN (?) AND payload IS NOT NULL
+            AND (payload->>'$.sealed.kid' IS NULL OR payload->>'$.sealed.kid' <> ?)
+        ORDER BY id ASC
+        LIMIT ?
+    `, d.rotateFrom.Load(), slots, d.keyring.Primary(), limit)
+	if err != nil {
+		return err
+	}
+	if err = d.db.SelectContext(ctx, &rows, d.db.Rebind(query), args...); err != nil {
+		return err
+	}
+	failed := 0
+	for _, row := range rows {
+		if err = d.rotateRow(ctx, row.TaskNo, row.Payload); err != nil {
+			failed++
+			d.collect(ctx, fmt.Errorf("rotate payload %d: %w", row.TaskNo, err))
+		}
+	}
+	// 未取满时本轮已到末尾，下次从头开始
+	if len(rows) < limit {
+		d.rotateFrom.Store(0)
+	} else {
+		d.rotateFrom.Store(rows[len(rows)-1].Id)
+	}
+	d.lg.Info(ctx, "Rotate Payload", "rows", len(rows), "failed", failed, "primary", d.keyring.Primary())
+	return nil
+}
+
+// rotateRow 重新加密单个任务：先处理 blob 中的 data，再更新 payload
+func (d *Storage) rotateRow(ctx context.Context, taskNo int64, p *callback.Payload) error {
+	var plain string
+	if p.Sealed == nil {
+		q := *p
+		var err error
+		if plain, err = d.rotateBlob(ctx, taskNo, &q); err != nil {
+			return err
+		}
+		sealed, err := d.seal(&TaskEntity{TaskNo: taskNo, Payload: &q})
+		if err != nil {
+			return err
+		}
+		p = sealed.Payload
+	} else {
+		if _, err := d.rotateBlob(ctx, taskNo, p); err != nil {
+			return err
+		}
+		s, _, err := d.keyring.Rewrap(p.Sealed)
+		if err != nil {
+			return err
+		}
+		p = &callback.Payload{Sealed: s}
+	}
+	if _, err := d.db.ExecContext(ctx, `
+        UPDATE task_queue SET payload=?, updated_at=updated_at WHERE task_no=?
+    `, p, taskNo); err != nil {
+		return err
+	}
+	if plain != "" {
+		if err := d.blob.Delete(ctx, plain); err != nil {
+			return fmt.Errorf("delete plaintext data: %w", err)
+		}
+	}
+	return nil
+}
+
+// rotateBlob 用主密钥重新加密 blob 中的 data：已加密的重新加密数据密钥，以便移除旧密钥；
+// 未加密的 payload 引用的明文 data 绑定任务编号加密后存入新 key 并更新 p.DataRef，返回待删除的明文 key
+func (d *Storage) rotateBlob(ctx context.Context, taskNo int64, p *callback.Payload) (string, error) {
+	if d.blob == nil {
+		return "", nil
+	}
+	if p.Sealed == nil {
+		if p.DataRef == "" || strings.HasSuffix(p.DataRef, sealedExt) {
+			return "", nil
+		}
+		b, err := d.blob.Get(ctx, p.DataRef)
+		if errors.Is(err, blob.ErrNotFound) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		s, err := d.keyring.Seal(b, aad(taskNo))
+		if err != nil {
+			return "", err
+		}
+		if b, err = json.Marshal(s); err != nil {
+			return "", err
+		}
+		key := blobKey(taskNo, sealedExt)
+		if err = d.blob.Put(ctx, key, b); err != nil {
+			return "", err
+		}
+		plain := p.DataRef
+		p.DataRef = key
+		return plain, nil
+	}
+
+	key := blobKey(taskNo, sealedExt)
+	b, err := d.blob.Get(ctx, key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	s := &keyring.Sealed{}
+	if err = json.Unmarshal(b, s); err != nil {
+		return "", err
+	}
+	r, ok, err := d.keyring.Rewrap(s)
+	if err != nil || !ok {
+		return "", err
+	}
+	if b, err = json.Marshal(r); err != nil {
+		return "", err
+	}
+	return "", d.blob.Put(ctx, key, b)
+}
+
+func (c *EncryptionConfig) rotateLimit() int {
+	if c.RotateLimit <= 0 {
+		return 100
+	}
+	return c.RotateLimit
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/x-thooh/delay/internal/service/push"
+	"github.com/x-thooh/delay/internal/service/storage/blob"
+	"github.com/x-thooh/delay/internal/service/storage/callback"
+	"github.com/x-thooh/delay/pkg/keyring"
+)
+
+func TestPayloadOffload(t *testing.T) {
+	ctx := context.Background()
+	bs, err := blob.NewFS(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Storage{
+		cfg:  &Config{Payload: &PayloadConfig{MaxSize: 64, InlineSize: 16}},
+		blob: bs,
+	}
+
+	if err = d.checkSize(&callback.Payload{Data: map[string]any{"a": strings.Repeat("x", 64)}}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected size error, got %v", err)
+	}
+
+	small := &callback.Payload{Data: map[string]any{"a": 1}}
+	if ref, err := d.offload(ctx, 1, small); err != nil || ref != "" || small.Data == nil {
+		t.Fatalf("unexpected offload %q %v", ref, err)
+	}
+
+	p := &callback.Payload{Data: map[string]any{"a": strings.Repeat("x", 32)}}
+	ref, err := d.offload(ctx, 258, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != "02/258.json" || p.DataRef != ref || p.Data != nil {
+		t.Fatalf("unexpected offload %q %+v", ref, p)
+	}
+	if err = d.load(ctx, 258, p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Data["a"] != strings.Repeat("x", 32) {
+		t.Fatalf("unexpected data %+v", p.Data)
+	}
+
+	d.discard(ref)
+	p.Data = nil
+	if err = d.load(ctx, 258, p); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestPayloadSeal(t *testing.T) {
+	ctx := context.Background()
+	bs, err := blob.NewFS(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr, err := keyring.New("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Storage{
+		cfg:     &Config{Payload: &PayloadConfig{InlineSize: 16}},
+		blob:    bs,
+		keyring: kr,
+	}
+
+	p := &callback.Payload{
+		Schema: "HTTP",
+		Url:    "http://sms.internal",
+		Data:   map[string]any{"phone": "13800000000", "text": strings.Repeat("x", 32)},
+	}
+	ref, err := d.offload(ctx, 1, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(ref, sealedExt) {
+		t.Fatalf("unexpected blob key %q", ref)
+	}
+	b, _ := bs.Get(ctx, ref)
+	if bytes.Contains(b, []byte("13800000000")) {
+		t.Fatalf("blob stored in plaintext: %s", b)
+	}
+
+	task := &TaskEntity{TaskNo: 1, Payload: p}
+	row, err := d.seal(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row == task || task.Payload.Sealed != nil {
+		t.Fatal("seal must not modify the task")
+	}
+	v, err := row.Payload.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(v.([]byte), []byte("sms.internal")) {
+		t.Fatalf("payload stored in plaintext: %s", v)
+	}
+
+	scanned := &callback.Payload{}
+	if err = scanned.Scan(v); err != nil {
+		t.Fatal(err)
+	}
+	if err = d.load(ctx, 1, scanned); err != nil {
+		t.Fatal(err)
+	}
+	if scanned.Url != "http://sms.internal" || scanned.Data["phone"] != "13800000000" {
+		b, _ := json.Marshal(scanned)
+		t.Fatalf("unexpected payload %s", b)
+	}
+
+	// 任务结束后删除 blob 中的 data
+	if err = d.purge(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = bs.Get(ctx, ref); !errors.Is(err, blob.ErrNotFound) {
+		t.Fatalf("expected blob deleted, got %v", err)
+	}
+}
+
+func TestPayloadRotateBlob(t *testing.T) {
+	ctx := context.Background()
+	bs, err := blob.NewFS(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	k1, k2 := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)
+	kr, err := keyring.New("k1", map[string][]byte{"k1": k1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Storage{
+		cfg:     &Config{Payload: &PayloadConfig{InlineSize: 16}},
+		blob:    bs,
+		keyring: kr,
+	}
+	p := &callback.Payload{Data: map[string]any{"text": strings.Repeat("x", 32)}}
+	ref, err := d.offload(ctx, 7, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 密文绑定任务编号
+	if err = d.load(ctx, 8, &callback.Payload{DataRef: ref}); err == nil {
+		t.Fatal("expected data of another task rejected")
+	}
+
+	if d.keyring, err = keyring.New("k2", map[string][]byte{"k1": k1, "k2": k2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = d.rotateBlob(ctx, 7, &callback.Payload{Sealed: &keyring.Sealed{}}); err != nil {
+		t.Fatal(err)
+	}
+	// 轮换后移除旧密钥仍可读取
+	if d.keyring, err = keyring.New("k2", map[string][]byte{"k2": k2}); err != nil {
+		t.Fatal(err)
+	}
+	if err = d.load(ctx, 7, p); err != nil || p.Data["text"] != strings.Repeat("x", 32) {
+		t.Fatalf("unexpected load %+v %v", p.Data, err)
+	}
+	// 没有 blob 的任务跳过
+	if _, err = d.rotateBlob(ctx, 9, &callback.Payload{Sealed: &keyring.Sealed{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未加密的任务：明文 data 加密后存入新 key
+	plain := blobKey(10, ".json")
+	if err = bs.Put(ctx, plain, []byte(`{"phone":"13800000000"}`)); err != nil {
+		t.Fatal(err)
+	}
+	q := &callback.Payload{DataRef: plain}
+	if ref, err = d.rotateBlob(ctx, 10, q); err != nil || ref != plain {
+		t.Fatalf("unexpected rotate %q %v", ref, err)
+	}
+	if q.DataRef != blobKey(10, sealedExt) {
+		t.Fatalf("unexpected data ref %q", q.DataRef)
+	}
+	b, _ := bs.Get(ctx, q.DataRef)
+	if bytes.Contains(b, []byte("13800000000")) {
+		t.Fatalf("blob stored in plaintext: %s", b)
+	}
+	if err = d.load(ctx, 10, q); err != nil || q.Data["phone"] != "13800000000" {
+		t.Fatalf("unexpected load %+v %v", q.Data, err)
+	}
+}
+
+func TestCheckSecret(t *testing.T) {
+	adapter, err := callback.NewRegistry(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adapter.Close(context.Background())
+	d := &Storage{cfg: &Config{}, adapter: adapter}
+
+	p := &callback.Payload{Schema: "FMT", Secret: "s3cr3t"}
+	if err = d.check(p); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected secret rejected without encryption, got %v", err)
+	}
+	if d.keyring, err = keyring.New("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}); err != nil {
+		t.Fatal(err)
+	}
+	if err = d.check(p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	adapter, err := callback.NewRegistry(nil, callback.WithHub(push.NewHub()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adapter.Close(context.Background())
+	d := &Storage{cfg: &Config{}, adapter: adapter}
+	d.slots.Store(&slotSet{nodes: []int{0}})
+
+	for _, p := range []*callback.Payload{
+		{Schema: "PUSH", Path: "countdown"},
+		{Schema: "EXEC", Path: "sh"},
+		{Schema: "HTTP", Url: "http://sms.internal", Path: "/send"},
+	} {
+		if err = d.check(p); err != nil {
+			t.Fatalf("check %s: %v", p.Schema, err)
+		}
+	}
+	if err = d.check(&callback.Payload{Schema: "HTTP", Path: "/send"}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected url required, got %v", err)
+	}
+
+	// 多节点时订阅者可能不在执行节点
+	d.slots.Store(&slotSet{nodes: []int{0, 1}})
+	if err = d.check(&callback.Payload{Schema: "PUSH", Path: "countdown"}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected push rejected, got %v", err)
+	}
+}
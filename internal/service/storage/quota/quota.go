@@ -0,0 +1,110 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+var ErrExceeded = errors.New("tenant quota exceeded")
+
+type Config struct {
+	Enable bool `yaml:"enable"`
+	// 默认配额，0 表示不限制
+	MaxPending int64   `yaml:"max_pending"`
+	Rate       float64 `yaml:"rate"`
+	Burst      int     `yaml:"burst"`
+	// 单独配置的租户
+	Tenants []*Tenant `yaml:"tenants"`
+}
+
+type Tenant struct {
+	Name       string  `yaml:"name"`
+	MaxPending int64   `yaml:"max_pending"`
+	Rate       float64 `yaml:"rate"`
+	Burst      int     `yaml:"burst"`
+}
+
+// Quota 租户配额：待处理任务数、每秒注册数
+type Quota struct {
+	cfg     *Config
+	tenants map[string]*Tenant
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func New(cfg *Config) *Quota {
+	if cfg == nil || !cfg.Enable {
+		return nil
+	}
+	q := &Quota{
+		cfg:      cfg,
+		tenants:  make(map[string]*Tenant, len(cfg.Tenants)),
+		limiters: make(map[string]*rate.Limiter),
+	}
+	for _, t := range cfg.Tenants {
+		q.tenants[t.Name] = t
+	}
+	return q
+}
+
+// Check 校验租户注册配额：先校验待处理任务数，再校验注册速率，pending 返回租户当前待处理任务数。
+// 校验不占用速率，任务写入成功后调用返回的 take 扣减
+func (q *Quota) Check(ctx context.Context, tenant string, pending func(ctx context.Context, tenant string) (int64, error)) (take func(), err error) {
+	take = func() {}
+	if q == nil {
+		return take, nil
+	}
+	t := q.tenant(tenant)
+	if t.MaxPending > 0 {
+		n, err := pending(ctx, tenant)
+		if err != nil {
+			return take, err
+		}
+		if n >= t.MaxPending {
+			return take, fmt.Errorf("%w: tenant %q pending tasks %d reach limit %d", ErrExceeded, tenant, n, t.MaxPending)
+		}
+	}
+	l := q.limiter(tenant, t)
+	if l == nil {
+		return take, nil
+	}
+	if l.Tokens() < 1 {
+		return take, fmt.Errorf("%w: tenant %q registrations over %v/s", ErrExceeded, tenant, t.Rate)
+	}
+	return func() { l.Allow() }, nil
+}
+
+func (q *Quota) tenant(name string) *Tenant {
+	if t, ok := q.tenants[name]; ok {
+		return t
+	}
+	return &Tenant{
+		Name:       name,
+		MaxPending: q.cfg.MaxPending,
+		Rate:       q.cfg.Rate,
+		Burst:      q.cfg.Burst,
+	}
+}
+
+func (q *Quota) limiter(name string, t *Tenant) *rate.Limiter {
+	if t.Rate <= 0 {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if l, ok := q.limiters[name]; ok {
+		return l
+	}
+	burst := t.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	l := rate.NewLimiter(rate.Limit(t.Rate), burst)
+	q.limiters[name] = l
+	return l
+}
@@ -0,0 +1,64 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	q := New(&Config{
+		Enable:     true,
+		MaxPending: 2,
+		Tenants: []*Tenant{
+			{Name: "order", MaxPending: 5, Rate: 1, Burst: 1},
+		},
+	})
+	ctx := context.Background()
+	pending := func(n int64) func(context.Context, string) (int64, error) {
+		return func(context.Context, string) (int64, error) {
+			return n, nil
+		}
+	}
+
+	if _, err := q.Check(ctx, "pay", pending(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Check(ctx, "pay", pending(2)); !errors.Is(err, ErrExceeded) {
+		t.Fatalf("expected exceeded, got %v", err)
+	}
+	// 单独配置的租户，待处理数超限时不占用速率
+	if _, err := q.Check(ctx, "order", pending(5)); !errors.Is(err, ErrExceeded) {
+		t.Fatalf("expected pending exceeded, got %v", err)
+	}
+	// 任务未写入时不扣减速率
+	if _, err := q.Check(ctx, "order", pending(4)); err != nil {
+		t.Fatal(err)
+	}
+	take, err := q.Check(ctx, "order", pending(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	take()
+	if _, err = q.Check(ctx, "order", pending(0)); !errors.Is(err, ErrExceeded) {
+		t.Fatalf("expected rate exceeded, got %v", err)
+	}
+
+	want := errors.New("db down")
+	if _, err = q.Check(ctx, "pay", func(context.Context, string) (int64, error) {
+		return 0, want
+	}); !errors.Is(err, want) {
+		t.Fatalf("expected pending error, got %v", err)
+	}
+
+	// 未开启时不校验
+	var off *Quota
+	take, err = off.Check(ctx, "pay", pending(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	take()
+	if New(&Config{}) != nil {
+		t.Fatal("disabled quota should be nil")
+	}
+}
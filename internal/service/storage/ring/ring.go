@@ -0,0 +1,77 @@
+// Package ring 任务分片：任务按编号落入固定槽位，槽位通过一致性哈希分配给存活节点，
+// 节点增减时只有少量槽位更换负责节点。
+package ring
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// Slots 槽位数，任务的槽位写入数据库，修改后已有任务需重新计算
+const Slots = 1024
+
+// replicas 每个节点的虚拟节点数
+const replicas = 160
+
+// Slot 任务槽位，与 MySQL 中 CRC32(task_no) % 1024 的结果一致
+func Slot(taskNo int64) int {
+	return int(crc32.ChecksumIEEE([]byte(strconv.FormatInt(taskNo, 10))) % Slots)
+}
+
+type point struct {
+	hash uint32
+	node int
+}
+
+// Ring 一致性哈希环，所有节点使用相同的节点列表得到相同的分配结果
+type Ring struct {
+	points []point
+}
+
+func New(nodes []int) *Ring {
+	r := &Ring{points: make([]point, 0, len(nodes)*replicas)}
+	for _, n := range nodes {
+		// 每次摘要产生 4 个虚拟节点
+		for i := 0; i < replicas/4; i++ {
+			sum := md5.Sum([]byte(strconv.Itoa(n) + "-" + strconv.Itoa(i)))
+			for j := 0; j < 4; j++ {
+				r.points = append(r.points, point{hash: binary.LittleEndian.Uint32(sum[j*4:]), node: n})
+			}
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash == r.points[j].hash {
+			return r.points[i].node < r.points[j].node
+		}
+		return r.points[i].hash < r.points[j].hash
+	})
+	return r
+}
+
+// Owner 槽位的负责节点，环为空时返回 -1
+func (r *Ring) Owner(slot int) int {
+	if len(r.points) == 0 {
+		return -1
+	}
+	sum := md5.Sum([]byte("slot-" + strconv.Itoa(slot)))
+	h := binary.LittleEndian.Uint32(sum[:])
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].node
+}
+
+// Slots 节点负责的槽位（升序）
+func (r *Ring) Slots(node int) []int {
+	var slots []int
+	for s := 0; s < Slots; s++ {
+		if r.Owner(s) == node {
+			slots = append(slots, s)
+		}
+	}
+	return slots
+}
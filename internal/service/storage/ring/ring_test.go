@@ -0,0 +1,61 @@
+package ring
+
+import (
+	"testing"
+)
+
+func TestSlot(t *testing.T) {
+	// SELECT CRC32(1234567890) % 1024 = 741
+	if s := Slot(1234567890); s != 741 {
+		t.Fatalf("unexpected slot %d", s)
+	}
+	counts := make([]int, Slots)
+	for i := int64(0); i < 100000; i++ {
+		// 雪花编号低位多为 0，槽位仍需分散
+		counts[Slot(1800000000000000000+i<<22)]++
+	}
+	for s, c := range counts {
+		if c == 0 {
+			t.Fatalf("slot %d is empty", s)
+		}
+	}
+}
+
+func TestRing(t *testing.T) {
+	if New(nil).Owner(0) != -1 {
+		t.Fatal("empty ring should have no owner")
+	}
+
+	r := New([]int{0, 1, 2})
+	total := 0
+	for _, n := range []int{0, 1, 2} {
+		c := len(r.Slots(n))
+		if c < Slots/3*7/10 || c > Slots/3*13/10 {
+			t.Fatalf("node %d owns %d slots", n, c)
+		}
+		total += c
+	}
+	if total != Slots {
+		t.Fatalf("slots not fully assigned: %d", total)
+	}
+
+	// 与节点顺序无关
+	if r2 := New([]int{2, 0, 1}); r2.Owner(100) != r.Owner(100) {
+		t.Fatal("owner depends on node order")
+	}
+
+	// 新增节点只从已有节点迁入槽位
+	r4 := New([]int{0, 1, 2, 3})
+	moved := 0
+	for s := 0; s < Slots; s++ {
+		if o := r4.Owner(s); o != r.Owner(s) {
+			if o != 3 {
+				t.Fatalf("slot %d moved from %d to %d", s, r.Owner(s), o)
+			}
+			moved++
+		}
+	}
+	if moved > Slots/2 {
+		t.Fatalf("too many slots moved: %d", moved)
+	}
+}
@@ -2,24 +2,45 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"math"
 	"runtime/debug"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/jmoiron/sqlx"
 	"github.com/panjf2000/ants"
+	"github.com/x-thooh/delay/internal/service/push"
+	"github.com/x-thooh/delay/internal/service/storage/blob"
+	"github.com/x-thooh/delay/internal/service/storage/breaker"
 	"github.com/x-thooh/delay/internal/service/storage/callback"
+	"github.com/x-thooh/delay/internal/service/storage/leader"
+	"github.com/x-thooh/delay/internal/service/storage/limiter"
+	"github.com/x-thooh/delay/internal/service/storage/quota"
+	"github.com/x-thooh/delay/internal/service/storage/ring"
+	"github.com/x-thooh/delay/pkg/keyring"
 	"github.com/x-thooh/delay/pkg/log"
+	"github.com/x-thooh/delay/pkg/tenant"
 	"github.com/x-thooh/delay/pkg/timingwheel"
+	"github.com/x-thooh/delay/pkg/timingwheel/bucket"
 	"github.com/x-thooh/delay/pkg/trace"
 )
 
+var (
+	ErrNotFound = errors.New("task not found")
+	ErrInvalid  = errors.New("invalid task")
+)
+
 type Storage struct {
 	cfg *Config
 	lg  log.Logger
@@ -29,9 +50,28 @@ type Storage struct {
 
 	ch chan error
 
-	adapter map[string]callback.ICallback
+	adapter *callback.Registry
+	blob    blob.Store
+	keyring *keyring.Keyring
+	limiter *limiter.Limiter
+	breaker *breaker.Breaker
+	quota   *quota.Quota
+
+	// 存活节点及本节点负责的槽位
+	slots atomic.Pointer[slotSet]
+	// 节点注册使用的实例标识
+	instance string
+	// 节点编号被其他实例占用的原因，非空时不再拉取、执行及新增任务
+	taken atomic.Pointer[error]
+	fatal chan error
 
-	ns []int
+	elector   leader.Elector
+	stopElect func(ctx context.Context)
+
+	// 重新加密的进度
+	rotateFrom atomic.Int64
+	// 本节点调度中的定时任务：task_no -> *bucket.Timer
+	crons sync.Map
 }
 
 type Config struct {
@@ -50,16 +90,33 @@ type Config struct {
 
 	TimeoutLimit    int           `yaml:"timeout_limit"`
 	TimeoutInterval time.Duration `yaml:"timeout_interval"`
+	// 执行超时后再等待该时间仍未结束的任务放回待处理，需大于快速路径时间，默认 1m
+	TimeoutGrace time.Duration `yaml:"timeout_grace"`
 
+	// StatefulSet Pod 全量同步周期，默认 30s
 	NodeInterval time.Duration `yaml:"node_interval"`
+	// Pod 变化后等待该时间内没有新的变化再重新分片，默认 1s
+	NodeDebounce time.Duration  `yaml:"node_debounce"`
+	Cluster      *ClusterConfig `yaml:"cluster"`
+	Leader       *leader.Config `yaml:"leader"`
 
 	FastPathTime time.Duration `yaml:"fast_path_time"`
+
+	// 成功回调响应保存的最大字节数，默认 4096，小于 0 不保存
+	ResultLimit int `yaml:"result_limit"`
+
+	RateLimit *limiter.Config  `yaml:"rate_limit"`
+	Breaker   *breaker.Config  `yaml:"breaker"`
+	Quota     *quota.Config    `yaml:"quota"`
+	Callback  *callback.Config `yaml:"callback"`
+	Payload   *PayloadConfig   `yaml:"payload"`
 }
 
 func New(
 	cfg *Config,
 	lg log.Logger,
 	db *sqlx.DB,
+	hub *push.Hub,
 ) (*Storage, error) {
 	tw, err := timingwheel.New(
 		cfg.Tick,
@@ -73,23 +130,88 @@ func New(
 	if err != nil {
 		return nil, err
 	}
-	sn, err := snowflake.NewNode(int64(cfg.Node))
+	adapter, err := callback.NewRegistry(cfg.Callback, callback.WithLogger(lg), callback.WithHub(hub))
 	if err != nil {
 		return nil, err
 	}
+	var (
+		bs blob.Store
+		kr *keyring.Keyring
+	)
+	if cfg.Payload != nil {
+		if bs, err = blob.New(cfg.Payload.Blob); err != nil {
+			_ = adapter.Close(context.Background())
+			return nil, err
+		}
+	}
+	if file := cfg.Payload.encryption().Keyring; file != "" {
+		if kr, err = keyring.Load(file); err != nil {
+			_ = adapter.Close(context.Background())
+			return nil, err
+		}
+	}
 	d := &Storage{
-		cfg:     cfg,
-		lg:      lg,
-		db:      db,
-		sn:      sn,
-		tw:      tw,
-		adapter: callback.GetAdapter(lg),
+		cfg:      cfg,
+		lg:       lg,
+		db:       db,
+		tw:       tw,
+		adapter:  adapter,
+		blob:     bs,
+		keyring:  kr,
+		limiter:  limiter.New(cfg.RateLimit),
+		breaker:  breaker.New(cfg.Breaker),
+		quota:    quota.New(cfg.Quota),
+		instance: instanceId(),
+		fatal:    make(chan error, 1),
+	}
+
+	ns := []int{cfg.Node}
+	if cfg.Cluster.enabled() {
+		ctx := context.Background()
+		if cfg.Node, err = d.join(ctx, cfg.Node); err != nil {
+			_ = adapter.Close(ctx)
+			return nil, fmt.Errorf("join cluster: %w", err)
+		}
+		if ns, err = d.LiveNodes(ctx); err != nil {
+			_ = adapter.Close(ctx)
+			return nil, err
+		}
+	}
+	if d.sn, err = snowflake.NewNode(int64(cfg.Node)); err != nil {
+		_ = adapter.Close(context.Background())
+		return nil, err
 	}
 
-	d.SetNodes([]int{cfg.Node})
+	d.SetNodes(ns)
 	return d, nil
 }
 
+func (c *Config) timeoutInterval() time.Duration {
+	if c.TimeoutInterval <= 0 {
+		return 10 * time.Second
+	}
+	return c.TimeoutInterval
+}
+
+func (c *Config) timeoutLimit() int {
+	if c.TimeoutLimit <= 0 {
+		return 100
+	}
+	return c.TimeoutLimit
+}
+
+func (c *Config) timeoutGrace() time.Duration {
+	if c.TimeoutGrace <= 0 {
+		return time.Minute
+	}
+	return c.TimeoutGrace
+}
+
+// Breakers 返回回调端点的熔断状态
+func (d *Storage) Breakers() []*breaker.Stat {
+	return d.breaker.Stats()
+}
+
 func (d *Storage) RegisterErrEvent(cb func(err error)) {
 	if d.ch == nil {
 		d.ch = make(chan error, 100)
@@ -107,24 +229,27 @@ func (d *Storage) collect(ctx context.Context, err error) {
 	}
 }
 
+// SetNodes 按存活节点重新分配槽位，并接管下线节点未完成的任务
 func (d *Storage) SetNodes(ns []int) {
-	d.lg.Info(context.Background(), "set nodes", "nodes", ns, "node", d.cfg.Node)
-	// 存活节点
-	if len(ns) == 0 {
-		ns = []int{d.cfg.Node}
-	}
-	ns = append([]int{-1}, ns...)
-	for i := 0; i < len(ns); i++ {
-		if ns[i] >= d.cfg.Node {
-			end := ns[i]
-			if i == len(ns)-1 {
-				end = math.MaxInt64
-			}
-			d.ns = []int{ns[i-1], end}
-			break
-		}
+	ctx := context.Background()
+	if d.taken.Load() != nil {
+		return
+	}
+	// 存活节点，始终包含本节点
+	ns = append([]int{d.cfg.Node}, ns...)
+	sort.Ints(ns)
+	ns = slices.Compact(ns)
+
+	prev := d.slots.Load()
+	if prev != nil && slices.Equal(prev.nodes, ns) {
+		return
+	}
+	cur := &slotSet{nodes: ns, slots: ring.New(ns).Slots(d.cfg.Node)}
+	d.slots.Store(cur)
+	d.lg.Info(ctx, "set nodes", "nodes", ns, "node", d.cfg.Node, "slots", len(cur.slots))
+	if err := d.reclaim(ctx, cur, prev == nil); err != nil {
+		d.collect(ctx, fmt.Errorf("reclaim tasks: %w", err))
 	}
-	d.lg.Info(context.Background(), "set nodes", "ns", d.ns)
 }
 
 func (d *Storage) Start(_ context.Context) error {
@@ -153,63 +278,107 @@ func (d *Storage) Start(_ context.Context) error {
 		return err
 	}
 
-	// // 超时
-	// if err := d.ScheduleFunc(d.cfg.TimeoutInterval, func(ctx context.Context) {
-	// 	d.lg.Debug(ctx, "Cron Timeout Start")
-	// 	defer func() {
-	// 		d.lg.Debug(ctx, "Cron Timeout End")
-	// 	}()
-	// 	timeoutTasks, fErr := d.FetchTimeoutTasks(ctx, d.cfg.TimeoutLimit)
-	// 	if fErr != nil {
-	// 		fErr = fmt.Errorf("fetch timeout tasks: %w", fErr)
-	// 		d.collect(ctx, fErr)
-	// 		return
-	// 	}
-	// 	d.lg.Debug(ctx, "Cron Timeout", slog.Any("tasks", timeoutTasks))
-	// 	for _, task := range timeoutTasks {
-	// 		if err := d.Failure(trace.Append(ctx, task.TraceId()), task.WithFailMsg(&FailMsg{
-	// 			Resp: "",
-	// 			Err:  fmt.Sprintf("task timeout, timtout:%vs", task.Timeout),
-	// 		})); err != nil {
-	// 			err = fmt.Errorf("fail task %d: %w", task.TaskNo, err)
-	// 			d.collect(ctx, err)
-	// 			continue
-	// 		}
-	// 	}
-	// }); err != nil {
-	// 	return err
-	// }
-	return d.tw.Start()
-}
+	// 父任务已结束但未处理的子任务
+	if err := d.ScheduleFunc(d.cfg.PendingInterval, func(ctx context.Context) {
+		waitingTasks, fErr := d.FetchWaitingTasks(ctx, d.cfg.PendingLimit)
+		if fErr != nil {
+			d.collect(ctx, fmt.Errorf("fetch waiting tasks: %w", fErr))
+			return
+		}
+		for _, taskNo := range waitingTasks {
+			if err := d.settle(ctx, taskNo); err != nil {
+				d.collect(ctx, fmt.Errorf("settle task %d: %w", taskNo, err))
+			}
+		}
+	}); err != nil {
+		return err
+	}
 
-func (d *Storage) Stop(ctx context.Context) error {
-	for _, a := range d.adapter {
-		if err := a.Close(ctx); err != nil {
+	// 节点心跳
+	if c := d.cfg.Cluster; c.enabled() {
+		if err := d.ScheduleFunc(c.heartbeat(), func(ctx context.Context) {
+			// 编号已被占用，等待应用停止
+			if d.taken.Load() != nil {
+				return
+			}
+			if err := d.refreshNodes(ctx); err != nil {
+				d.collect(ctx, fmt.Errorf("refresh nodes: %w", err))
+			}
+		}); err != nil {
 			return err
 		}
+		if err := d.ScheduleSingleton(c.ttl(), func(ctx context.Context) {
+			if err := d.evict(ctx); err != nil {
+				d.collect(ctx, fmt.Errorf("evict nodes: %w", err))
+			}
+		}); err != nil {
+			return err
+		}
+	}
+
+	// 按主密钥重新加密
+	if enc := d.cfg.Payload.encryption(); d.keyring != nil && enc.RotateInterval > 0 {
+		if err := d.ScheduleFunc(enc.RotateInterval, func(ctx context.Context) {
+			if err := d.rotate(ctx); err != nil {
+				d.collect(ctx, fmt.Errorf("rotate payload: %w", err))
+			}
+		}); err != nil {
+			return err
+		}
+	}
+
+	// 超时恢复
+	if err := d.ScheduleSingleton(d.cfg.timeoutInterval(), func(ctx context.Context) {
+		if err := d.recoverTimeout(ctx); err != nil {
+			d.collect(ctx, fmt.Errorf("recover timeout tasks: %w", err))
+		}
+	}); err != nil {
+		return err
 	}
+
+	d.elect()
+	return d.tw.Start()
+}
+
+func (d *Storage) Stop(ctx context.Context) error {
 	d.tw.Stop()
-	return nil
+	if d.stopElect != nil {
+		d.stopElect(ctx)
+	}
+	var err error
+	if d.cfg.Cluster.enabled() {
+		err = d.leave(ctx)
+	}
+	if taken := d.taken.Load(); taken != nil {
+		err = errors.Join(*taken, err)
+	}
+	return errors.Join(err, d.adapter.Close(ctx))
 }
 
 type TaskEntity struct {
 	Id           int64             `db:"id"`
 	TaskNo       int64             `db:"task_no"`
+	Tenant       string            `db:"tenant"`
 	Payload      *callback.Payload `db:"payload"`
 	DelayTime    int64             `db:"delay_time"`
 	Timeout      int64             `db:"timeout"`
 	Backoff      *JSONSliceInt64   `db:"backoff"` // JSON array
 	CronExpr     string            `db:"cron_expr"`
-	Status       int               `db:"status"` // 0待执行 1执行中 2成功 3失败
+	Status       int               `db:"status"` // 0待执行 1执行中 2成功 3失败 4已取消 5等待父任务
 	NextRunAt    time.Time         `db:"next_run_at"`
 	RunTimeoutAt time.Time         `db:"run_timeout_at"`
 	FailCount    int               `db:"fail_count"`
 	LastRetryAt  *time.Time        `db:"last_retry_at"`
-	LockedBy     int64             `db:"locked_by"`
+	LockedBy     int64             `db:"locked_by"` // 执行节点
+	Slot         int               `db:"slot"`
 	FailMsgs     *FailMsgs         `db:"fail_msgs"`
+	Result       *string           `db:"result"`
 	Extra        *Extra            `db:"extra"`
 	CreatedAt    time.Time         `db:"created_at"`
 	UpdatedAt    time.Time         `db:"updated_at"`
+
+	// 定时任务在本节点的定时器
+	cron *bucket.Timer
 }
 
 func (t *TaskEntity) TraceId() string {
@@ -265,6 +434,8 @@ func (j *FailMsgs) Append(fm *FailMsg) *FailMsgs {
 
 type Extra struct {
 	TraceId string `json:"trace_id"`
+	// 父任务失败或取消时子任务的处理方式
+	OnParentFailure string `json:"on_parent_failure,omitempty"`
 }
 
 func (j Extra) Value() (driver.Value, error) {
@@ -278,7 +449,53 @@ func (j *Extra) Scan(src interface{}) error {
 	return json.Unmarshal(b, j)
 }
 
+const insertTask = `
+		INSERT INTO task_queue
+        (task_no, tenant, payload, delay_time, timeout, backoff, cron_expr, status, next_run_at, run_timeout_at, fail_count, locked_by, slot, extra, created_at, updated_at)
+        VALUES
+        (:task_no,:tenant,:payload,:delay_time,:timeout,:backoff,:cron_expr,:status,:next_run_at,:run_timeout_at,:fail_count,:locked_by,:slot,:extra,:created_at,:updated_at)
+    `
+
 func (d *Storage) Add(ctx context.Context, opts ...Option) (int64, error) {
+	n, err := d.build(ctx, opts...)
+	if err != nil {
+		return 0, err
+	}
+	if len(n.parents) > 0 {
+		if err = d.addChild(ctx, n.row, n.parents); err != nil {
+			d.discard(n.ref)
+			return 0, err
+		}
+		return n.task.TaskNo, nil
+	}
+	if err = d.create(ctx, n.row); err != nil {
+		d.discard(n.ref)
+		return 0, err
+	}
+	if err = d.schedule(ctx, n); err != nil {
+		return 0, err
+	}
+	return n.task.TaskNo, nil
+}
+
+// newTask 已生成、待写入数据库的任务
+type newTask struct {
+	task *TaskEntity
+	// 加密后写入数据库的行
+	row *TaskEntity
+	// 外置存储的回调数据，写入失败时删除
+	ref     string
+	parents []int64
+	// 延迟在快速通道内，写入后由本节点直接调度
+	fast bool
+}
+
+// build 校验选项并生成任务
+func (d *Storage) build(ctx context.Context, opts ...Option) (*newTask, error) {
+	// 占用同一编号的实例会生成重复的任务编号
+	if taken := d.taken.Load(); taken != nil {
+		return nil, *taken
+	}
 	o := &options{
 		delayTime: 5,
 		timeout:   3,
@@ -290,13 +507,36 @@ func (d *Storage) Add(ctx context.Context, opts ...Option) (int64, error) {
 	for _, opt := range opts {
 		opt(o)
 	}
+	if o.timeout <= 0 {
+		// 回调超时由任务超时控制，未设置时使用默认值
+		o.timeout = 3
+	}
+	switch o.parentFailure {
+	case "", ParentFailureCancel, ParentFailureFail, ParentFailureRun:
+	default:
+		return nil, fmt.Errorf("%w: on_parent_failure %q", ErrInvalid, o.parentFailure)
+	}
+	if len(o.parents) > 0 && o.cron != "" {
+		return nil, fmt.Errorf("%w: cron task cannot have parents", ErrInvalid)
+	}
+	for p := o.payload; p != nil; p = p.OnComplete {
+		if err := d.check(p); err != nil {
+			return nil, err
+		}
+	}
+	tn := tenant.Get(ctx)
 	taskNo := d.sn.Generate().Int64()
 	now := time.Now()
+	ref, err := d.offload(ctx, taskNo, o.payload)
+	if err != nil {
+		return nil, err
+	}
 
 	nextRun := now.Add(time.Duration(o.delayTime) * time.Second)
 	runTimeout := nextRun.Add(time.Duration(o.timeout) * time.Second)
 	task := &TaskEntity{
 		TaskNo:    taskNo,
+		Tenant:    tn,
 		Payload:   o.payload,
 		DelayTime: o.delayTime,
 		Timeout:   o.timeout,
@@ -310,58 +550,228 @@ func (d *Storage) Add(ctx context.Context, opts ...Option) (int64, error) {
 		RunTimeoutAt: runTimeout,
 		FailCount:    -1,
 		LockedBy:     int64(d.cfg.Node),
+		Slot:         ring.Slot(taskNo),
 		Extra: &Extra{
 			TraceId: trace.Get(ctx),
 		},
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
-	d.lg.Info(ctx, "Create Task", "task_no", task.TaskNo, "delay_time", task.DelayTime)
-	flag := false
-	if time.Duration(o.delayTime)*time.Second <= d.cfg.FastPathTime {
-		flag = true
+	d.lg.Info(ctx, "Create Task", "task_no", task.TaskNo, "tenant", task.Tenant, "delay_time", task.DelayTime, "parents", o.parents)
+	n := &newTask{task: task, ref: ref, parents: o.parents}
+	if len(o.parents) == 0 && time.Duration(o.delayTime)*time.Second <= d.cfg.FastPathTime {
+		n.fast = true
 		task.Status = 1
 		task.FailCount = 0
 	}
-	query := `
-		INSERT INTO task_queue
-        (task_no, payload, delay_time, timeout, backoff, cron_expr, status, next_run_at, run_timeout_at, fail_count, locked_by, extra, created_at, updated_at)
-        VALUES
-        (:task_no,:payload,:delay_time,:timeout,:backoff,:cron_expr,:status,:next_run_at,:run_timeout_at,:fail_count,:locked_by,:extra,:created_at,:updated_at)
-    `
-	_, err := d.db.NamedExecContext(ctx, query, task)
+	if len(o.parents) > 0 {
+		task.Extra.OnParentFailure = o.parentFailure
+	}
+	if n.row, err = d.seal(task); err != nil {
+		d.discard(ref)
+		return nil, err
+	}
+	return n, nil
+}
+
+// schedule 写入成功后将快速通道的任务交给本节点调度
+func (d *Storage) schedule(ctx context.Context, n *newTask) error {
+	if !n.fast {
+		return nil
+	}
+	return d.Submit(trace.Set(context.Background(), trace.Get(ctx)), n.task, -1)
+}
+
+// check 校验回调实例、回调地址及请求体模板
+func (d *Storage) check(p *callback.Payload) error {
+	c, ok := d.adapter.Get(p.Schema)
+	if !ok {
+		return fmt.Errorf("%w: unknown schema %q", ErrInvalid, p.Schema)
+	}
+	switch c.(type) {
+	case *callback.Push:
+		// 订阅只在所连接的节点有效，多节点时任务可能在没有订阅者的节点执行
+		if d.clustered() {
+			return fmt.Errorf("%w: schema %q is not supported with multiple nodes", ErrInvalid, p.Schema)
+		}
+	case *callback.Exec, *callback.Fmt:
+	default:
+		if p.Url == "" {
+			return fmt.Errorf("%w: url is required for schema %q", ErrInvalid, p.Schema)
+		}
+	}
+	if err := d.checkSize(p); err != nil {
+		return err
+	}
+	if p.Secret != "" && d.keyring == nil {
+		// 未加密时密钥会以明文写入数据库
+		return fmt.Errorf("%w: secret requires payload encryption", ErrInvalid)
+	}
+	switch p.Mode {
+	case "", callback.ModeRaw:
+	case callback.ModeEnvelope:
+		if p.Template != "" || (p.ContentType != "" && p.ContentType != callback.ContentTypeJSON) {
+			return fmt.Errorf("%w: envelope mode requires json content type without template", ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: mode %q", ErrInvalid, p.Mode)
+	}
+	if p.Template != "" {
+		if _, err := callback.ParseTemplate(p.Template); err != nil {
+			return fmt.Errorf("%w: template: %v", ErrInvalid, err)
+		}
+	}
+	return nil
+}
+
+// create 在事务中插入任务
+func (d *Storage) create(ctx context.Context, row *TaskEntity) error {
+	tx, err := d.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	if flag {
-		if err = d.Submit(trace.Set(context.Background(), trace.Get(ctx)), task, -1); err != nil {
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	take, err := d.insert(ctx, tx, row)
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	take()
+	return nil
+}
+
+// insert 校验租户配额后插入任务：统计前锁定租户行，同一租户的并发注册串行执行，避免超出配额；
+// 返回的 take 在事务提交后调用，扣减租户注册速率
+func (d *Storage) insert(ctx context.Context, tx *sqlx.Tx, row *TaskEntity) (func(), error) {
+	take, err := d.quota.Check(ctx, row.Tenant, func(ctx context.Context, tn string) (int64, error) {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO tenant_quota (tenant, updated_at) VALUES (?, ?)
+            ON DUPLICATE KEY UPDATE updated_at=VALUES(updated_at)
+        `, tn, time.Now()); err != nil {
 			return 0, err
 		}
+		return countPending(ctx, tx, tn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.NamedExecContext(ctx, insertTask, row); err != nil {
+		return nil, err
+	}
+	return take, nil
+}
+
+// CountPending 统计租户未完成的任务数
+func (d *Storage) CountPending(ctx context.Context, tn string) (int64, error) {
+	return countPending(ctx, d.db, tn)
+}
+
+func countPending(ctx context.Context, q sqlx.QueryerContext, tn string) (int64, error) {
+	var n int64
+	err := sqlx.GetContext(ctx, q, &n, `
+        SELECT COUNT(*) FROM task_queue
+        WHERE tenant=? AND status IN (0,1,5)
+    `, tn)
+	return n, err
+}
+
+// Get 查询当前租户的任务
+func (d *Storage) Get(ctx context.Context, taskNo int64) (*TaskEntity, error) {
+	task := &TaskEntity{}
+	err := d.db.GetContext(ctx, task, `
+        SELECT * FROM task_queue
+        WHERE task_no=? AND tenant=?
+    `, taskNo, tenant.Get(ctx))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if finished(task.Status) {
+		// 结束的任务已删除外置的 data，只解密
+		if task.Payload != nil && task.Payload.Sealed != nil {
+			err = d.open(task.TaskNo, task.Payload)
+		}
+	} else {
+		err = d.load(ctx, task.TaskNo, task.Payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// 签名密钥不对外返回
+	for p := task.Payload; p != nil; p = p.OnComplete {
+		p.Secret = ""
+	}
+	return task, nil
+}
+
+// Cancel 取消当前租户未完成的任务
+func (d *Storage) Cancel(ctx context.Context, taskNo int64) (bool, error) {
+	ret, err := d.db.ExecContext(ctx, `
+        UPDATE task_queue
+        SET status=4, updated_at=?
+        WHERE task_no=? AND tenant=? AND status IN (0,1,5)
+    `, time.Now(), taskNo, tenant.Get(ctx))
+	if err != nil {
+		return false, err
+	}
+	n, err := ret.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	d.lg.Info(ctx, "Cancel Task", "task_no", taskNo, "canceled", n > 0)
+	if n > 0 {
+		// 其他节点上的定时任务在下次执行时检查状态后停止
+		d.stopCron(taskNo, nil)
+		if err = d.finish(ctx, taskNo); err != nil {
+			d.collect(ctx, fmt.Errorf("resolve canceled task %d: %w", taskNo, err))
+		}
 	}
-	return task.TaskNo, nil
+	return n > 0, nil
 }
 
 func (d *Storage) FetchPendingTasks(ctx context.Context, maxCount int, t time.Duration) ([]*TaskEntity, error) {
+	slots := d.owned()
+	if len(slots) == 0 {
+		return nil, nil
+	}
 	now := time.Now().Add(t)
-	var tasks []*TaskEntity
-	err := d.db.SelectContext(ctx, &tasks, `
+	query, args, err := sqlx.In(`
         SELECT * FROM task_queue
-        WHERE status=0 AND next_run_at <= ? AND locked_by > ? AND locked_by <= ?
+        WHERE status=0 AND next_run_at <= ? AND slot IN (?)
         ORDER BY next_run_at ASC 
         LIMIT ?  FOR UPDATE SKIP LOCKED
-    `, now, d.ns[0], d.ns[1], maxCount)
+    `, now, slots, maxCount)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []*TaskEntity
+	err = d.db.SelectContext(ctx, &tasks, d.db.Rebind(query), args...)
 	return tasks, err
 }
 
 func (d *Storage) FetchTimeoutTasks(ctx context.Context, maxCount int) ([]*TaskEntity, error) {
+	slots := d.owned()
+	if len(slots) == 0 {
+		return nil, nil
+	}
 	now := time.Now()
-	var tasks []*TaskEntity
-	err := d.db.SelectContext(ctx, &tasks, `
+	query, args, err := sqlx.In(`
         SELECT * FROM task_queue
-        WHERE status=1 AND run_timeout_at <= ? AND locked_by > ? AND locked_by <= ?
+        WHERE status=1 AND run_timeout_at <= ? AND slot IN (?)
         ORDER BY run_timeout_at ASC
         LIMIT ? FOR UPDATE SKIP LOCKED
-    `, now, d.ns[0], d.ns[1], maxCount)
+    `, now, slots, maxCount)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []*TaskEntity
+	err = d.db.SelectContext(ctx, &tasks, d.db.Rebind(query), args...)
 	return tasks, err
 }
 
@@ -373,22 +783,60 @@ func (d *Storage) Execute(ctx context.Context, task *TaskEntity) (err error) {
 	defer func() {
 		d.lg.Info(ctx, "Executing End", "task_no", fmt.Sprintf("%d-%d", task.TaskNo, failCount), "delay_time", delayTime, "resp", resp, "err", err)
 	}()
-	rCtx, cancelFunc := context.WithDeadline(trace.Set(context.Background(), trace.Get(ctx)), task.RunTimeoutAt)
+	meta := &callback.Meta{
+		TaskNo:      task.TaskNo,
+		Tenant:      task.Tenant,
+		Attempt:     failCount + 1,
+		ScheduledAt: task.NextRunAt,
+		FiredAt:     time.Now(),
+	}
+	rCtx, cancelFunc := context.WithDeadline(callback.NewContext(trace.Set(context.Background(), trace.Get(ctx)), meta), task.RunTimeoutAt)
 	defer cancelFunc()
-	adapter, ok := d.adapter[strings.ToUpper(task.Payload.Schema)]
+	var cur struct {
+		Status   int   `db:"status"`
+		LockedBy int64 `db:"locked_by"`
+	}
+	// 节点编号已被其他实例占用，任务由对方执行
+	if d.taken.Load() != nil {
+		d.lg.Info(ctx, "Executing Skipped", "task_no", fmt.Sprintf("%d-%d", task.TaskNo, failCount), "err", ErrNodeTaken)
+		d.stopCron(task.TaskNo, task.cron)
+		return nil
+	}
+	if err = d.db.GetContext(ctx, &cur, `SELECT status, locked_by FROM task_queue WHERE task_no=?`, task.TaskNo); err != nil {
+		return err
+	}
+	// 已取消、已被处理或已被其他节点接管，定时任务由接管节点调度
+	if cur.LockedBy != int64(d.cfg.Node) || cur.Status != 1 {
+		d.lg.Info(ctx, "Executing Skipped", "task_no", fmt.Sprintf("%d-%d", task.TaskNo, failCount), "status", cur.Status, "locked_by", cur.LockedBy)
+		d.stopCron(task.TaskNo, task.cron)
+		return nil
+	}
+	if err = d.load(ctx, task.TaskNo, task.Payload); err != nil {
+		return d.Failure(ctx, task.WithFailMsg(&FailMsg{Err: err.Error()}))
+	}
+	adapter, ok := d.adapter.Get(task.Payload.Schema)
 	if !ok {
 		return d.Failure(ctx, task.WithFailMsg(&FailMsg{
 			Resp: "",
 			Err:  "adapter not found for schema " + task.Payload.Schema,
 		}))
 	}
-	if task.Payload.Data == nil {
-		task.Payload.Data = make(map[string]interface{})
+	// 先判断熔断，熔断推迟时不消耗限流令牌
+	endpoint := task.Payload.Url + task.Payload.Path
+	if retryAt, allow := d.breaker.Allow(endpoint); !allow {
+		d.lg.Info(ctx, "Executing Broken", "task_no", fmt.Sprintf("%d-%d", task.TaskNo, failCount), "endpoint", endpoint, "retry_at", retryAt)
+		return d.Defer(ctx, task, retryAt)
+	}
+	if wait := d.limiter.Reserve(task.Payload); wait > 0 {
+		d.lg.Info(ctx, "Executing Limited", "task_no", fmt.Sprintf("%d-%d", task.TaskNo, failCount), "wait", wait)
+		d.breaker.Release(endpoint)
+		return d.Defer(ctx, task, time.Now().Add(wait))
 	}
-	task.Payload.Data["original"] = map[string]interface{}{"msg_no": task.TaskNo, "trace_id": trace.Get(ctx)}
 	resp, err = adapter.Request(rCtx, task.Payload)
-	if err == nil && strings.Trim(resp, `"'`+"`") == "SUCCESS" {
-		return d.Success(ctx, task)
+	ok = succeeded(meta, resp, err)
+	d.breaker.Report(endpoint, ok)
+	if ok {
+		return d.Success(ctx, task, resp)
 	}
 	return d.Failure(ctx, task.WithFailMsg(&FailMsg{
 		Resp: resp,
@@ -401,6 +849,15 @@ func (d *Storage) Execute(ctx context.Context, task *TaskEntity) (err error) {
 	}))
 }
 
+// succeeded 回调是否成功，熔断上报与任务状态使用同一判断：适配器已判定成功（HTTP 2xx、gRPC 结果字段、EXEC 退出码），
+// 或响应为 SUCCESS
+func succeeded(meta *callback.Meta, resp string, err error) bool {
+	if err != nil {
+		return false
+	}
+	return meta.Succeeded || strings.Trim(resp, `"'`+"`") == "SUCCESS"
+}
+
 func (d *Storage) GetDelayTime(task *TaskEntity) int64 {
 	tdd := task.DelayTime
 	if task.FailCount > 0 {
@@ -409,14 +866,123 @@ func (d *Storage) GetDelayTime(task *TaskEntity) int64 {
 	return tdd
 }
 
-func (d *Storage) Success(ctx context.Context, task *TaskEntity) error {
+func (d *Storage) Success(ctx context.Context, task *TaskEntity, resp string) error {
 	d.lg.Info(ctx, "Executing Success", "task_no", fmt.Sprintf("%d-%d", task.TaskNo, task.FailCount), "delay_time", d.GetDelayTime(task), "task", task)
-	_, err := d.db.Exec(`
+	var result *string
+	if d.cfg.ResultLimit >= 0 {
+		r := truncate(resp, d.cfg.ResultLimit)
+		result = &r
+	}
+	query := `
         UPDATE task_queue
-        SET status=2, updated_at = ?
+        SET status=2, result=?, updated_at = ?
         WHERE task_no=? AND status=1
-    `, time.Now(), task.TaskNo)
-	return err
+    `
+	cron := len(task.CronExpr) != 0
+	if cron {
+		// 定时任务保持执行中，等待下次调度
+		task.FailCount, task.FailMsgs = 0, nil
+		query = `
+            UPDATE task_queue
+            SET fail_count=0, fail_msgs=NULL, result=?, updated_at=?
+            WHERE task_no=? AND status=1
+        `
+		if !d.owns(ring.Slot(task.TaskNo)) {
+			// 槽位已移交其他节点，放回待处理由新节点调度，拉取时会重新累加失败次数
+			d.stopCron(task.TaskNo, task.cron)
+			query = `
+                UPDATE task_queue
+                SET status=0, fail_count=-1, fail_msgs=NULL, result=?, updated_at=?
+                WHERE task_no=? AND status=1
+            `
+		}
+	}
+	// 转发失败只记录错误，原任务始终标记成功，避免重复执行原回调
+	var fwd *newTask
+	if task.Payload.OnComplete != nil {
+		var parent string
+		if result != nil {
+			parent = *result
+		}
+		var err error
+		if fwd, err = d.build(tenant.Set(ctx, task.Tenant), forwardOptions(task, parent)...); err != nil {
+			d.collect(ctx, fmt.Errorf("forward task %d: %w", task.TaskNo, err))
+		}
+	}
+	ok, err := d.complete(ctx, query, result, task.TaskNo, fwd)
+	if err != nil && fwd != nil {
+		d.discard(fwd.ref)
+		d.collect(ctx, fmt.Errorf("forward task %d: %w", task.TaskNo, err))
+		fwd = nil
+		ok, err = d.complete(ctx, query, result, task.TaskNo, nil)
+	}
+	if err != nil || !ok {
+		if fwd != nil {
+			d.discard(fwd.ref)
+		}
+		return err
+	}
+	if fwd != nil {
+		d.lg.Info(ctx, "Forward Task", "task_no", task.TaskNo, "on_complete", fwd.task.TaskNo)
+		if err = d.schedule(ctx, fwd); err != nil {
+			d.collect(ctx, fmt.Errorf("schedule forward task %d: %w", fwd.task.TaskNo, err))
+		}
+	}
+	if cron {
+		return nil
+	}
+	return d.finish(ctx, task.TaskNo)
+}
+
+// complete 在同一事务中记录成功结果并写入 on_complete 转发任务，任务已不在执行中时返回 false；
+// 转发任务是已接受任务的后续，不计入租户配额
+func (d *Storage) complete(ctx context.Context, query string, result *string, taskNo int64, fwd *newTask) (bool, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	ret, err := tx.ExecContext(ctx, query, result, time.Now(), taskNo)
+	if err != nil {
+		return false, err
+	}
+	// 仅由成功更新状态的节点转发及调度子任务，避免重复
+	if n, err := ret.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	if fwd != nil {
+		if _, err = tx.NamedExecContext(ctx, insertTask, fwd.row); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// forwardOptions 将成功响应（已按 result_limit 截断）作为 data.parent 转发到 on_complete 回调，
+// 转发任务沿用原任务的租户、超时及重试
+func forwardOptions(task *TaskEntity, resp string) []Option {
+	p := *task.Payload.OnComplete
+	data := make(map[string]any, len(p.Data)+1)
+	for k, v := range p.Data {
+		data[k] = v
+	}
+	var result any
+	if err := json.Unmarshal([]byte(resp), &result); err != nil {
+		result = resp
+	}
+	data["parent"] = map[string]any{
+		"task_no": strconv.FormatInt(task.TaskNo, 10),
+		"result":  result,
+	}
+	p.Data = data
+
+	opts := []Option{WithPayload(&p), WithDelayTime(0), WithTimeout(task.Timeout)}
+	if task.Backoff != nil {
+		opts = append(opts, WithBackoff(*task.Backoff...))
+	}
+	return opts
 }
 
 func (d *Storage) Failure(ctx context.Context, task *TaskEntity) error {
@@ -429,7 +995,8 @@ func (d *Storage) Failure(ctx context.Context, task *TaskEntity) error {
 		task.NextRunAt = now.Add(time.Duration(task.DelayTime) * time.Second)
 		task.RunTimeoutAt = task.NextRunAt.Add(time.Duration(task.Timeout) * time.Second)
 		task.LastRetryAt = &now
-		if time.Duration(task.DelayTime)*time.Second <= d.cfg.FastPathTime {
+		// 槽位已移交其他节点时放回待处理，由新节点重试
+		if time.Duration(task.DelayTime)*time.Second <= d.cfg.FastPathTime && d.owns(ring.Slot(task.TaskNo)) {
 			task.FailCount++
 			if err := d.Submit(ctx, task, 1); err != nil {
 				return err
@@ -451,6 +1018,45 @@ func (d *Storage) Failure(ctx context.Context, task *TaskEntity) error {
         SET status=3, fail_msgs = ?, updated_at=?
         WHERE task_no=?
     `, task.FailMsgs, now, task.TaskNo)
+	if err != nil {
+		return err
+	}
+	return d.finish(ctx, task.TaskNo)
+}
+
+// Defer 推迟任务到指定时间执行，不消耗重试次数
+func (d *Storage) Defer(ctx context.Context, task *TaskEntity, until time.Time) error {
+	now := time.Now()
+	task.NextRunAt = until
+	task.RunTimeoutAt = until.Add(time.Duration(task.Timeout) * time.Second)
+	if until.Sub(now) <= d.cfg.FastPathTime && d.owns(ring.Slot(task.TaskNo)) {
+		// 同步执行时间，避免超时恢复将推迟中的任务重复放回
+		ret, err := d.db.ExecContext(ctx, `
+            UPDATE task_queue
+            SET next_run_at=?, run_timeout_at=?, updated_at=?
+            WHERE task_no=? AND status=1 AND locked_by=?
+        `, task.NextRunAt, task.RunTimeoutAt, now, task.TaskNo, d.cfg.Node)
+		if err != nil {
+			return err
+		}
+		if n, err := ret.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+		return d.AfterFunc(ctx, until.Sub(now), func() {
+			if err := d.Execute(ctx, task); err != nil {
+				err = fmt.Errorf("execute deferred task %d: %w", task.TaskNo, err)
+				d.collect(ctx, err)
+				return
+			}
+		})
+	}
+
+	// 放回待处理，拉取时会重新累加失败次数
+	_, err := d.db.ExecContext(ctx, `
+        UPDATE task_queue
+        SET status=0, fail_count=?, next_run_at=?, run_timeout_at=?, updated_at=?
+        WHERE task_no=? AND status=1
+    `, task.FailCount-1, task.NextRunAt, task.RunTimeoutAt, now, task.TaskNo)
 	return err
 }
 
@@ -463,12 +1069,13 @@ func (d *Storage) Submit(ctx context.Context, task *TaskEntity, status int) erro
 		}
 		_, err := d.db.ExecContext(ctx, `
             UPDATE task_queue
-            SET status=1, fail_count=?, fail_msgs=?, next_run_at=?, run_timeout_at=?, last_retry_at=?, updated_at=?
+            SET status=1, fail_count=?, fail_msgs=?, next_run_at=?, run_timeout_at=?, last_retry_at=?, locked_by=?, updated_at=?
             WHERE task_no=? AND status=?
-        `, task.FailCount, task.FailMsgs, task.NextRunAt, task.RunTimeoutAt, lastRetryAt, now, task.TaskNo, status)
+        `, task.FailCount, task.FailMsgs, task.NextRunAt, task.RunTimeoutAt, lastRetryAt, d.cfg.Node, now, task.TaskNo, status)
 		if err != nil {
 			return err
 		}
+		task.LockedBy = int64(d.cfg.Node)
 
 	}
 	delayTime := task.NextRunAt.Sub(now)
@@ -480,7 +1087,7 @@ func (d *Storage) Submit(ctx context.Context, task *TaskEntity, status int) erro
 		}
 		return dd.Seconds()
 	}(), delayTime.Seconds()))
-	if task.DelayTime != 0 {
+	if fireOnce(task) {
 		if err := d.AfterFunc(ctx, delayTime, func() {
 			if err := d.Execute(ctx, task); err != nil {
 				err = fmt.Errorf("execute after task %d: %w", task.TaskNo, err)
@@ -497,8 +1104,8 @@ func (d *Storage) Submit(ctx context.Context, task *TaskEntity, status int) erro
 		if err != nil {
 			return err
 		}
-		if _, err = d.tw.ScheduleFunc(&timingwheel.EveryScheduler{Interval: duration}, func() {
-			if err = d.Execute(ctx, task); err != nil {
+		if task.cron, err = d.tw.ScheduleFunc(&timingwheel.EveryScheduler{Interval: duration}, func() {
+			if err := d.Execute(ctx, task); err != nil {
 				err = fmt.Errorf("execute schedule task %d: %w", task.TaskNo, err)
 				d.collect(ctx, err)
 				return
@@ -506,10 +1113,32 @@ func (d *Storage) Submit(ctx context.Context, task *TaskEntity, status int) erro
 		}); err != nil {
 			return err
 		}
+		// 重新提交（如快速重试）时替换原定时器
+		if prev, ok := d.crons.Swap(task.TaskNo, task.cron); ok && prev.(*bucket.Timer) != task.cron {
+			prev.(*bucket.Timer).Stop()
+		}
 	}
 	return nil
 }
 
+// fireOnce 是否按 next_run_at 触发一次：有延迟的任务，以及延迟为 0 的非定时任务（如 on_complete 转发）；
+// 延迟为 0 的定时任务只由周期定时器触发，避免首个周期执行两次
+func fireOnce(task *TaskEntity) bool {
+	return task.DelayTime != 0 || len(task.CronExpr) == 0
+}
+
+// stopCron 停止本节点上定时任务的定时器，t 为空时停止当前登记的定时器
+func (d *Storage) stopCron(taskNo int64, t *bucket.Timer) {
+	if t == nil {
+		if v, ok := d.crons.LoadAndDelete(taskNo); ok {
+			v.(*bucket.Timer).Stop()
+		}
+		return
+	}
+	t.Stop()
+	d.crons.CompareAndDelete(taskNo, t)
+}
+
 func (d *Storage) AfterFunc(ctx context.Context, td time.Duration, f func()) (err error) {
 	_, err = d.tw.AfterFunc(td, func() {
 		defer func() {
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log"
 	"testing"
 	"time"
@@ -13,6 +14,8 @@ import (
 	"github.com/qustavo/sqlhooks/v2"
 	"github.com/x-thooh/delay/internal/boot/database"
 	"github.com/x-thooh/delay/internal/service/storage/callback"
+	"github.com/x-thooh/delay/internal/service/storage/leader"
+	"github.com/x-thooh/delay/internal/service/storage/ring"
 	plog "github.com/x-thooh/delay/pkg/log"
 	"github.com/x-thooh/delay/pkg/log/xslog"
 )
@@ -59,6 +62,8 @@ func setLogger() plog.Logger {
 
 func cleanupTasks(db *sqlx.DB) {
 	db.Exec("DELETE FROM task_queue")
+	db.Exec("DELETE FROM task_dependency")
+	db.Exec("DELETE FROM node_registry")
 }
 
 func TestAddImmediateTask(t *testing.T) {
@@ -68,7 +73,7 @@ func TestAddImmediateTask(t *testing.T) {
 	ctx := context.Background()
 	defer cleanupTasks(db)
 
-	delay, err := New(cfg, lg, db)
+	delay, err := New(cfg, lg, db, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -101,7 +106,7 @@ func TestAddImmediateTask_SUCCESS(t *testing.T) {
 	ctx := context.Background()
 	defer cleanupTasks(db)
 
-	delay, err := New(cfg, lg, db)
+	delay, err := New(cfg, lg, db, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -139,7 +144,7 @@ func TestAddDelayTask(t *testing.T) {
 	ctx := context.Background()
 	defer cleanupTasks(db)
 
-	delay, err := New(cfg, lg, db)
+	delay, err := New(cfg, lg, db, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -172,7 +177,7 @@ func TestAddDelayTask_SUCCESS(t *testing.T) {
 	ctx := context.Background()
 	defer cleanupTasks(db)
 
-	delay, err := New(cfg, lg, db)
+	delay, err := New(cfg, lg, db, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -210,7 +215,7 @@ func TestAddDelayTask_GRPC(t *testing.T) {
 	ctx := context.Background()
 	defer cleanupTasks(db)
 
-	delay, err := New(cfg, lg, db)
+	delay, err := New(cfg, lg, db, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -250,7 +255,7 @@ func TestAddDelayTask_HTTP(t *testing.T) {
 	ctx := context.Background()
 	defer cleanupTasks(db)
 
-	delay, err := New(cfg, lg, db)
+	delay, err := New(cfg, lg, db, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -282,3 +287,380 @@ func TestAddDelayTask_HTTP(t *testing.T) {
 	t.Log("Inserted immediate task, wait 5 seconds to see execution", time.Now())
 	time.Sleep(15 * time.Second)
 }
+
+func TestAddOnComplete(t *testing.T) {
+	cfg := setConfig()
+	lg := setLogger()
+	db := setupDB(lg)
+	ctx := context.Background()
+	defer cleanupTasks(db)
+
+	delay, err := New(cfg, lg, db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = delay.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer delay.Stop(ctx)
+
+	ret, err := delay.Add(ctx, WithPayload(&callback.Payload{
+		Schema: "fmt",
+		Data: map[string]any{
+			"result": "SUCCESS",
+		},
+		OnComplete: &callback.Payload{
+			Schema: "fmt",
+			Data: map[string]any{
+				"result": "SUCCESS",
+			},
+		},
+	}), WithDelayTime(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(5 * time.Second)
+	task, err := delay.Get(ctx, ret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != 2 || task.Result == nil || *task.Result != "SUCCESS" {
+		t.Fatalf("unexpected task %+v", task)
+	}
+	var tasks []*TaskEntity
+	if err = db.Select(&tasks, `SELECT * FROM task_queue WHERE task_no<>?`, ret); err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Status != 2 {
+		t.Fatalf("unexpected forwarded tasks %+v", tasks)
+	}
+	parent, _ := tasks[0].Payload.Data["parent"].(map[string]any)
+	if parent["result"] != "SUCCESS" {
+		t.Fatalf("unexpected parent %+v", parent)
+	}
+}
+
+func TestAddChildTask(t *testing.T) {
+	cfg := setConfig()
+	lg := setLogger()
+	db := setupDB(lg)
+	ctx := context.Background()
+	defer cleanupTasks(db)
+
+	delay, err := New(cfg, lg, db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = delay.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer delay.Stop(ctx)
+
+	ok, err := delay.Add(ctx, WithPayload(&callback.Payload{
+		Schema: "fmt",
+		Data:   map[string]any{"result": "SUCCESS"},
+	}), WithDelayTime(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fail, err := delay.Add(ctx, WithPayload(&callback.Payload{
+		Schema: "fmt",
+	}), WithDelayTime(1), WithBackoff())
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := delay.Add(ctx, WithPayload(&callback.Payload{
+		Schema: "fmt",
+		Data:   map[string]any{"result": "SUCCESS"},
+	}), WithDelayTime(1), WithParents(ok))
+	if err != nil {
+		t.Fatal(err)
+	}
+	canceled, err := delay.Add(ctx, WithDelayTime(1), WithParents(ok, fail))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, err := delay.Add(ctx, WithPayload(&callback.Payload{
+		Schema: "fmt",
+		Data:   map[string]any{"result": "SUCCESS"},
+	}), WithDelayTime(1), WithParents(fail), WithParentFailure(ParentFailureRun))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = delay.Add(ctx, WithParents(-1)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected invalid parent, got %v", err)
+	}
+	cron, err := delay.Add(ctx, WithDelayTime(60), WithCron("0 */1 * * * *"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = delay.Add(ctx, WithParents(cron)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected cron parent rejected, got %v", err)
+	}
+
+	time.Sleep(6 * time.Second)
+	for taskNo, want := range map[int64]int{child: 2, canceled: 4, run: 2} {
+		task, err := delay.Get(ctx, taskNo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.Status != want {
+			t.Fatalf("task %d status %d, want %d", taskNo, task.Status, want)
+		}
+	}
+}
+
+func TestClusterJoin(t *testing.T) {
+	lg := setLogger()
+	db := setupDB(lg)
+	ctx := context.Background()
+	defer cleanupTasks(db)
+
+	newNode := func(node int, auto bool) (*Storage, error) {
+		cfg := setConfig()
+		cfg.Node = node
+		cfg.Cluster = &ClusterConfig{Enable: true, Heartbeat: time.Second, TTL: 2 * time.Second, AutoNode: auto}
+		return New(cfg, lg, db, nil)
+	}
+
+	a, err := newNode(0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newNode(0, false); !errors.Is(err, ErrNodeTaken) {
+		t.Fatalf("expected ErrNodeTaken, got %v", err)
+	}
+	b, err := newNode(0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.cfg.Node != 1 {
+		t.Fatalf("expected auto node 1, got %d", b.cfg.Node)
+	}
+	if err = a.refreshNodes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(a.owned()); n == 0 || n+len(b.owned()) != ring.Slots {
+		t.Fatalf("unexpected slots %d + %d", n, len(b.owned()))
+	}
+	// b 的编号被其他实例占用后不再负责任务，也不再生成任务编号
+	if _, err = db.Exec(`UPDATE node_registry SET instance='other' WHERE node_id=1`); err != nil {
+		t.Fatal(err)
+	}
+	if err = b.refreshNodes(ctx); !errors.Is(err, ErrNodeTaken) {
+		t.Fatalf("expected ErrNodeTaken, got %v", err)
+	}
+	if len(b.owned()) != 0 {
+		t.Fatalf("unexpected slots %d", len(b.owned()))
+	}
+	select {
+	case err = <-b.Fatal():
+		if !errors.Is(err, ErrNodeTaken) {
+			t.Fatalf("expected ErrNodeTaken, got %v", err)
+		}
+	default:
+		t.Fatal("expected fatal error")
+	}
+	if _, err = b.Add(ctx); !errors.Is(err, ErrNodeTaken) {
+		t.Fatalf("expected ErrNodeTaken, got %v", err)
+	}
+	// b 执行中的任务，槽位属于 a
+	if _, err = db.Exec(`
+        INSERT INTO task_queue (task_no, status, fail_count, locked_by, slot) VALUES (42, 1, 0, 1, ?)
+    `, a.owned()[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// b 停止心跳后被剔除，a 接管全部槽位及 b 执行中的任务
+	time.Sleep(3 * time.Second)
+	if err = a.evict(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.refreshNodes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ns, _ := a.LiveNodes(ctx); len(ns) != 1 || ns[0] != 0 {
+		t.Fatalf("unexpected live nodes %v", ns)
+	}
+	if len(a.owned()) != ring.Slots {
+		t.Fatalf("unexpected slots %d", len(a.owned()))
+	}
+	var task TaskEntity
+	if err = db.Get(&task, `SELECT * FROM task_queue WHERE task_no=42`); err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != 0 || task.FailCount != -1 {
+		t.Fatalf("task not reclaimed %+v", task)
+	}
+
+	// 被剔除的节点编号空闲时心跳重新占用
+	if err = b.heartbeat(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = b.leave(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.leave(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSlotHandover(t *testing.T) {
+	lg := setLogger()
+	db := setupDB(lg)
+	ctx := context.Background()
+	defer cleanupTasks(db)
+
+	newNode := func(node int) *Storage {
+		cfg := setConfig()
+		cfg.Node = node
+		cfg.Cluster = &ClusterConfig{Enable: true, Heartbeat: time.Second, TTL: 2 * time.Second}
+		d, err := New(cfg, lg, db, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	a := newNode(0)
+	defer a.leave(ctx)
+
+	// 加入 b 后槽位从 a 移到 b 的任务
+	moved := func(from int64) int64 {
+		r := ring.New([]int{0, 1})
+		for taskNo := from; ; taskNo++ {
+			if r.Owner(ring.Slot(taskNo)) == 1 {
+				return taskNo
+			}
+		}
+	}
+	retry := moved(1)
+	cronNo := moved(retry + 1)
+	for _, taskNo := range []int64{retry, cronNo} {
+		expr := ""
+		if taskNo == cronNo {
+			expr = "*/5 * * * * *"
+		}
+		if _, err := db.Exec(`
+            INSERT INTO task_queue (task_no, status, fail_count, locked_by, slot, cron_expr, next_run_at, backoff, payload)
+            VALUES (?, 1, 0, 0, ?, ?, NOW(), '[1]', '{"schema":"fmt"}')
+        `, taskNo, ring.Slot(taskNo), expr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := newNode(1)
+	defer b.leave(ctx)
+	if err := a.refreshNodes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// 两个节点都存活，a 执行中的任务不被回收
+	var status int
+	if err := db.Get(&status, `SELECT status FROM task_queue WHERE task_no=?`, retry); err != nil || status != 1 {
+		t.Fatalf("unexpected status %d %v", status, err)
+	}
+
+	// a 本次执行结束后放回待处理，由 b 拉取
+	task, err := a.Get(ctx, retry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = a.Failure(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	task, err = a.Get(ctx, cronNo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = a.Success(ctx, task, "SUCCESS"); err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := b.FetchPendingTasks(ctx, 10, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks handed over, got %d", len(tasks))
+	}
+}
+
+func TestLeaderLease(t *testing.T) {
+	lg := setLogger()
+	db := setupDB(lg)
+	ctx := context.Background()
+	defer db.Exec("DELETE FROM leader_lease")
+
+	cfg := &leader.Config{Type: leader.TypeDB, Name: "test-leader", TTL: time.Second}
+	newNode := func(id string) *Storage {
+		d, err := New(setConfig(), lg, db, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.SetElector(leader.NewDB(cfg, db, lg, id))
+		if err = d.Start(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	a := newNode("a")
+	time.Sleep(100 * time.Millisecond)
+	b := newNode("b")
+	defer b.Stop(ctx)
+
+	time.Sleep(500 * time.Millisecond)
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("unexpected leaders a=%v b=%v", a.IsLeader(), b.IsLeader())
+	}
+
+	// a 退出时释放租约，b 下次续约时接替
+	if err := a.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	if a.IsLeader() || !b.IsLeader() {
+		t.Fatalf("unexpected leaders a=%v b=%v", a.IsLeader(), b.IsLeader())
+	}
+}
+
+func TestSucceeded(t *testing.T) {
+	for _, c := range []struct {
+		resp      string
+		err       error
+		succeeded bool
+		want      bool
+	}{
+		{"SUCCESS", nil, false, true},
+		{`"SUCCESS"`, nil, false, true},
+		{`{"exit_code":0,"stdout":"done"}`, nil, true, true},
+		{`{"exit_code":3,"stderr":"boom"}`, nil, false, false},
+		{`{"code":"SUCCESS"}`, nil, false, false},
+		{"OK", nil, false, false},
+		{`{"charge_id":"c-1"}`, nil, true, true},
+		{"SUCCESS", errors.New("timeout"), false, false},
+		{"", errors.New("http status 500"), true, false},
+	} {
+		meta := &callback.Meta{Succeeded: c.succeeded}
+		if got := succeeded(meta, c.resp, c.err); got != c.want {
+			t.Fatalf("succeeded(%v, %q, %v) = %v, want %v", c.succeeded, c.resp, c.err, got, c.want)
+		}
+	}
+}
+
+func TestFireOnce(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		task *TaskEntity
+		want bool
+	}{
+		{"delayed", &TaskEntity{DelayTime: 5}, true},
+		{"immediate", &TaskEntity{}, true},
+		{"delayed cron", &TaskEntity{DelayTime: 5, CronExpr: "*/5 * * * * *"}, true},
+		{"immediate cron", &TaskEntity{CronExpr: "*/5 * * * * *"}, false},
+	} {
+		if got := fireOnce(c.task); got != c.want {
+			t.Fatalf("%s: fireOnce = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
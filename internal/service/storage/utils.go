@@ -5,8 +5,23 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// truncate 截断超过 n 字节的字符串，不截断多字节字符
+func truncate(s string, n int) string {
+	if n == 0 {
+		n = 4096
+	}
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "...(truncated)"
+}
+
 // CronToDuration converts a standard 6-field cron expression into a time.Duration.
 // Supported formats:
 //
@@ -0,0 +1,318 @@
+// Package kafkatest 进程内的 Kafka 测试 broker，仅支持 kafka 包使用的 Metadata v5 与 Produce v7。
+package kafkatest
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/x-thooh/delay/pkg/kafka"
+)
+
+type Broker struct {
+	lis net.Listener
+
+	mu         sync.Mutex
+	partitions int
+	records    map[string][][]*kafka.Record
+	failures   []int16
+	leaderless map[int]bool
+	conns      map[net.Conn]struct{}
+}
+
+// NewBroker 启动单节点 broker，主题在首次请求时自动创建
+func NewBroker(partitions int) (*Broker, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	b := &Broker{
+		lis:        lis,
+		partitions: partitions,
+		records:    make(map[string][][]*kafka.Record),
+		conns:      make(map[net.Conn]struct{}),
+		leaderless: make(map[int]bool),
+	}
+	go b.serve()
+	return b, nil
+}
+
+func (b *Broker) Addr() string {
+	return b.lis.Addr().String()
+}
+
+// FailProduce 之后的 Produce 请求依次返回给定错误码
+func (b *Broker) FailProduce(codes ...int16) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = append(b.failures, codes...)
+}
+
+// Leaderless 元数据中给定分区的 leader 为 -1，传入空时恢复
+func (b *Broker) Leaderless(partitions ...int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clear(b.leaderless)
+	for _, p := range partitions {
+		b.leaderless[p] = true
+	}
+}
+
+// Records 返回主题指定分区已写入的消息
+func (b *Broker) Records(topic string, partition int) []*kafka.Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ps := b.records[topic]
+	if partition >= len(ps) {
+		return nil
+	}
+	return append([]*kafka.Record(nil), ps[partition]...)
+}
+
+func (b *Broker) Close() error {
+	err := b.lis.Close()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.conns {
+		_ = c.Close()
+	}
+	return err
+}
+
+func (b *Broker) serve() {
+	for {
+		c, err := b.lis.Accept()
+		if err != nil {
+			return
+		}
+		b.mu.Lock()
+		b.conns[c] = struct{}{}
+		b.mu.Unlock()
+		go b.handle(c)
+	}
+}
+
+func (b *Broker) handle(c net.Conn) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, c)
+		b.mu.Unlock()
+		_ = c.Close()
+	}()
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(c, size[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(c, req); err != nil {
+			return
+		}
+		r := &reader{b: req}
+		api, version, cid := r.int16(), r.int16(), r.int32()
+		r.string()
+
+		var (
+			resp []byte
+			err  error
+		)
+		switch {
+		case api == 3 && version == 5:
+			resp, err = b.metadata(r)
+		case api == 0 && version == 7:
+			var acks int16
+			resp, acks, err = b.produce(r)
+			if err == nil && acks == 0 {
+				continue
+			}
+		default:
+			err = errors.New("unsupported api " + strconv.Itoa(int(api)) + " v" + strconv.Itoa(int(version)))
+		}
+		if err != nil {
+			return
+		}
+		out := binary.BigEndian.AppendUint32(nil, uint32(len(resp)+4))
+		out = binary.BigEndian.AppendUint32(out, uint32(cid))
+		if _, err = c.Write(append(out, resp...)); err != nil {
+			return
+		}
+	}
+}
+
+func (b *Broker) topic(name string) {
+	if _, ok := b.records[name]; !ok {
+		b.records[name] = make([][]*kafka.Record, b.partitions)
+	}
+}
+
+func (b *Broker) metadata(r *reader) ([]byte, error) {
+	var topics []string
+	for i, n := 0, int(r.int32()); i < n; i++ {
+		topics = append(topics, r.string())
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	host, port, _ := net.SplitHostPort(b.Addr())
+	p, _ := strconv.Atoi(port)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	w := &writer{}
+	w.int32(0)
+	w.int32(1)
+	w.int32(1)
+	w.string(host)
+	w.int32(int32(p))
+	w.int16(-1)
+	w.int16(-1)
+	w.int32(1)
+	w.int32(int32(len(topics)))
+	for _, t := range topics {
+		b.topic(t)
+		w.int16(0)
+		w.string(t)
+		w.int8(0)
+		w.int32(int32(b.partitions))
+		for i := 0; i < b.partitions; i++ {
+			w.int16(0)
+			w.int32(int32(i))
+			if b.leaderless[i] {
+				w.int32(-1)
+			} else {
+				w.int32(1)
+			}
+			for k := 0; k < 2; k++ {
+				w.int32(1)
+				w.int32(1)
+			}
+			w.int32(0)
+		}
+	}
+	return w.b, nil
+}
+
+func (b *Broker) produce(r *reader) ([]byte, int16, error) {
+	r.string()
+	acks := r.int16()
+	r.int32()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	w := &writer{}
+	tn := int(r.int32())
+	w.int32(int32(tn))
+	for i := 0; i < tn; i++ {
+		name := r.string()
+		w.string(name)
+		pn := int(r.int32())
+		w.int32(int32(pn))
+		for j := 0; j < pn; j++ {
+			idx := r.int32()
+			data := r.bytes()
+			if r.err != nil {
+				return nil, 0, r.err
+			}
+			var (
+				code   int16
+				offset int64 = -1
+			)
+			if len(b.failures) > 0 {
+				code, b.failures = b.failures[0], b.failures[1:]
+			} else {
+				records, err := kafka.DecodeBatch(data)
+				if err != nil {
+					return nil, 0, err
+				}
+				b.topic(name)
+				ps := b.records[name]
+				if int(idx) >= len(ps) {
+					code = 3
+				} else {
+					offset = int64(len(ps[idx]))
+					ps[idx] = append(ps[idx], records...)
+				}
+			}
+			w.int32(idx)
+			w.int16(code)
+			w.int64(offset)
+			w.int64(-1)
+			w.int64(0)
+		}
+	}
+	w.int32(0)
+	return w.b, acks, r.err
+}
+
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) take(n int) []byte {
+	if r.err != nil || n < 0 || len(r.b) < n {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *reader) int16() int16 {
+	if v := r.take(2); v != nil {
+		return int16(binary.BigEndian.Uint16(v))
+	}
+	return 0
+}
+
+func (r *reader) int32() int32 {
+	if v := r.take(4); v != nil {
+		return int32(binary.BigEndian.Uint32(v))
+	}
+	return 0
+}
+
+func (r *reader) string() string {
+	n := r.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(r.take(int(n)))
+}
+
+func (r *reader) bytes() []byte {
+	n := r.int32()
+	if n < 0 {
+		return nil
+	}
+	return r.take(int(n))
+}
+
+type writer struct {
+	b []byte
+}
+
+func (w *writer) int8(v int8) {
+	w.b = append(w.b, byte(v))
+}
+
+func (w *writer) int16(v int16) {
+	w.b = binary.BigEndian.AppendUint16(w.b, uint16(v))
+}
+
+func (w *writer) int32(v int32) {
+	w.b = binary.BigEndian.AppendUint32(w.b, uint32(v))
+}
+
+func (w *writer) int64(v int64) {
+	w.b = binary.BigEndian.AppendUint64(w.b, uint64(v))
+}
+
+func (w *writer) string(s string) {
+	w.int16(int16(len(s)))
+	w.b = append(w.b, s...)
+}
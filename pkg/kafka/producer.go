@@ -0,0 +1,449 @@
+// Package kafka 精简的 Kafka 生产者，仅实现同步发送所需的 Metadata（v5）与 Produce（v7）协议，
+// 消息使用不压缩的 v2 RecordBatch，兼容 Kafka 1.0+ 及 Redpanda 等兼容实现。
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// 初始 broker 地址
+	Brokers  []string
+	ClientID string
+	// 确认级别：all 全部同步副本（默认），leader，none 不等待确认
+	Acks string
+	// broker 端等待副本确认的超时，默认 10s
+	Timeout     time.Duration
+	DialTimeout time.Duration
+	// 元数据过期、无 leader 等可重试错误的重试次数，默认 3
+	Retries int
+	// 首次重试前的等待时间，之后每次翻倍，默认 100ms
+	Backoff time.Duration
+	TLS     *tls.Config
+}
+
+// Producer 同步生产者，并发安全
+type Producer struct {
+	cfg *Config
+
+	mu      sync.Mutex
+	conns   map[string]*conn
+	brokers map[int32]string
+	topics  map[string][]int32 // 分区 leader
+}
+
+func NewProducer(cfg *Config) (*Producer, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("kafka: no brokers")
+	}
+	c := *cfg
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.DialTimeout <= 0 {
+		c.DialTimeout = 5 * time.Second
+	}
+	if c.Retries <= 0 {
+		c.Retries = 3
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = 100 * time.Millisecond
+	}
+	if c.ClientID == "" {
+		c.ClientID = "delay"
+	}
+	switch c.Acks {
+	case "", "all", "leader", "none":
+	default:
+		return nil, fmt.Errorf("kafka: unsupported acks %q", c.Acks)
+	}
+	return &Producer{
+		cfg:     &c,
+		conns:   make(map[string]*conn),
+		brokers: make(map[int32]string),
+		topics:  make(map[string][]int32),
+	}, nil
+}
+
+// Send 发送一条消息，返回写入的分区与偏移量；acks 为 none 时偏移量为 -1
+func (p *Producer) Send(ctx context.Context, m *Message) (int32, int64, error) {
+	var err error
+	for attempt := 0; attempt <= p.cfg.Retries; attempt++ {
+		if attempt > 0 {
+			if err = p.backoff(ctx, attempt); err != nil {
+				return 0, 0, err
+			}
+		}
+		var (
+			leaders []int32
+			pi      int32
+			offset  int64
+		)
+		if leaders, err = p.partitions(ctx, m.Topic, attempt > 0); err != nil {
+			if isRetriable(err) {
+				continue
+			}
+			return 0, 0, err
+		}
+		if pi, err = pick(m, leaders); err != nil {
+			continue
+		}
+		if offset, err = p.produce(ctx, leaders[pi], m, pi); err == nil {
+			return pi, offset, nil
+		}
+		if !isRetriable(err) {
+			return 0, 0, err
+		}
+	}
+	return 0, 0, fmt.Errorf("kafka: send to %s after %d retries: %w", m.Topic, p.cfg.Retries, err)
+}
+
+// pick 选择分区：有键时按键哈希，分区无 leader 则失败；无键时随机选择有 leader 的分区
+func pick(m *Message, leaders []int32) (int32, error) {
+	if m.Key != nil {
+		pi := partition(m.Key, len(leaders))
+		if leaders[pi] < 0 {
+			return 0, fmt.Errorf("kafka: partition %d of %s has no leader: %w", pi, m.Topic, Error(errLeaderNotAvailable))
+		}
+		return pi, nil
+	}
+	avail := make([]int32, 0, len(leaders))
+	for i, l := range leaders {
+		if l >= 0 {
+			avail = append(avail, int32(i))
+		}
+	}
+	if len(avail) == 0 {
+		return 0, fmt.Errorf("kafka: no partition of %s has a leader: %w", m.Topic, Error(errLeaderNotAvailable))
+	}
+	return avail[rand.IntN(len(avail))], nil
+}
+
+// backoff 第 attempt 次重试前等待，等待时间指数增长
+func (p *Producer) backoff(ctx context.Context, attempt int) error {
+	t := time.NewTimer(p.cfg.Backoff << (attempt - 1))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func isRetriable(err error) bool {
+	var e Error
+	return errors.As(err, &e) && e.Retriable()
+}
+
+func (p *Producer) produce(ctx context.Context, leader int32, m *Message, pi int32) (int64, error) {
+	p.mu.Lock()
+	addr, ok := p.brokers[leader]
+	p.mu.Unlock()
+	if !ok {
+		return 0, Error(errLeaderNotAvailable)
+	}
+
+	e := &encoder{}
+	e.nullableString(nil)
+	e.int16(p.acks())
+	e.int32(int32(p.cfg.Timeout / time.Millisecond))
+	e.int32(1)
+	e.string(m.Topic)
+	e.int32(1)
+	e.int32(pi)
+	e.bytes(encodeBatch([]*Message{m}))
+
+	resp, err := p.roundTrip(ctx, addr, apiProduce, produceVersion, e.b, p.acks() == 0)
+	if err != nil || p.acks() == 0 {
+		return -1, err
+	}
+
+	d := &decoder{b: resp}
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		d.string()
+		for j, pn := 0, d.arrayLen(); j < pn; j++ {
+			d.int32()
+			code := d.int16()
+			offset := d.int64()
+			d.int64()
+			d.int64()
+			if d.err != nil {
+				return 0, d.err
+			}
+			if code != 0 {
+				return 0, Error(code)
+			}
+			return offset, nil
+		}
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return 0, errors.New("kafka: empty produce response")
+}
+
+func (p *Producer) acks() int16 {
+	switch p.cfg.Acks {
+	case "none":
+		return 0
+	case "leader":
+		return 1
+	}
+	return -1
+}
+
+// partitions 返回主题各分区的 leader，refresh 为 true 时重新获取元数据
+func (p *Producer) partitions(ctx context.Context, topic string, refresh bool) ([]int32, error) {
+	p.mu.Lock()
+	leaders, ok := p.topics[topic]
+	p.mu.Unlock()
+	if ok && !refresh {
+		return leaders, nil
+	}
+
+	e := &encoder{}
+	e.int32(1)
+	e.string(topic)
+	e.bool(true)
+
+	var err error
+	for _, addr := range p.addrs() {
+		var resp []byte
+		if resp, err = p.roundTrip(ctx, addr, apiMetadata, metadataVersion, e.b, false); err != nil {
+			continue
+		}
+		return p.updateMetadata(topic, resp)
+	}
+	return nil, err
+}
+
+// addrs 已知 broker 与初始 broker 地址
+func (p *Producer) addrs() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	addrs := make([]string, 0, len(p.brokers)+len(p.cfg.Brokers))
+	seen := make(map[string]bool)
+	for _, a := range p.brokers {
+		if !seen[a] {
+			seen[a] = true
+			addrs = append(addrs, a)
+		}
+	}
+	for _, a := range p.cfg.Brokers {
+		if !seen[a] {
+			seen[a] = true
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
+func (p *Producer) updateMetadata(topic string, resp []byte) ([]int32, error) {
+	d := &decoder{b: resp}
+	d.int32()
+	brokers := make(map[int32]string)
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string()
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	d.string()
+	d.int32()
+
+	var (
+		leaders []int32
+		code    int16
+	)
+	for i, n := 0, d.arrayLen(); i < n; i++ {
+		tc := d.int16()
+		name := d.string()
+		d.bool()
+		pn := d.arrayLen()
+		// 分区编号为 [0, pn)，未返回的分区视为无 leader
+		ls := make([]int32, pn)
+		for j := range ls {
+			ls[j] = -1
+		}
+		for j := 0; j < pn; j++ {
+			d.int16()
+			idx := d.int32()
+			leader := d.int32()
+			for k := 0; k < 3; k++ {
+				for r, rn := 0, d.arrayLen(); r < rn; r++ {
+					d.int32()
+				}
+			}
+			if d.err != nil {
+				return nil, d.err
+			}
+			if idx < 0 || int(idx) >= pn {
+				return nil, fmt.Errorf("kafka: invalid partition %d of %s (%d partitions)", idx, name, pn)
+			}
+			ls[idx] = leader
+		}
+		if name == topic {
+			leaders, code = ls, tc
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for id, addr := range brokers {
+		p.brokers[id] = addr
+	}
+	if code != 0 {
+		delete(p.topics, topic)
+		return nil, Error(code)
+	}
+	if len(leaders) == 0 {
+		return nil, Error(errUnknownTopicOrPartition)
+	}
+	p.topics[topic] = leaders
+	return leaders, nil
+}
+
+func (p *Producer) roundTrip(ctx context.Context, addr string, api, version int16, body []byte, noResponse bool) ([]byte, error) {
+	c, err := p.conn(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.roundTrip(ctx, p.cfg.ClientID, api, version, body, noResponse)
+	if err != nil {
+		var e Error
+		if !errors.As(err, &e) {
+			// 连接异常，丢弃后重建
+			p.mu.Lock()
+			if p.conns[addr] == c {
+				delete(p.conns, addr)
+			}
+			p.mu.Unlock()
+			_ = c.Close()
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (p *Producer) conn(ctx context.Context, addr string) (*conn, error) {
+	p.mu.Lock()
+	if c, ok := p.conns[addr]; ok {
+		p.mu.Unlock()
+		return c, nil
+	}
+	p.mu.Unlock()
+
+	dialer := &net.Dialer{Timeout: p.cfg.DialTimeout}
+	nc, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if p.cfg.TLS != nil {
+		tc := p.cfg.TLS.Clone()
+		if tc.ServerName == "" {
+			tc.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		tlsConn := tls.Client(nc, tc)
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			_ = nc.Close()
+			return nil, err
+		}
+		nc = tlsConn
+	}
+
+	c := &conn{Conn: nc}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if exist, ok := p.conns[addr]; ok {
+		_ = nc.Close()
+		return exist, nil
+	}
+	p.conns[addr] = c
+	return c, nil
+}
+
+func (p *Producer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var err error
+	for addr, c := range p.conns {
+		delete(p.conns, addr)
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// maxResponseSize 响应的最大字节数，与 broker 默认的 socket.request.max.bytes 一致，
+// 避免异常长度导致大量分配
+const maxResponseSize = 100 << 20
+
+// conn broker 连接，请求串行执行
+type conn struct {
+	net.Conn
+	mu  sync.Mutex
+	cid int32
+}
+
+func (c *conn) roundTrip(ctx context.Context, clientID string, api, version int16, body []byte, noResponse bool) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(30 * time.Second)
+	}
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	c.cid++
+	e := &encoder{}
+	e.int32(0)
+	e.int16(api)
+	e.int16(version)
+	e.int32(c.cid)
+	e.string(clientID)
+	e.b = append(e.b, body...)
+	e.putInt32(0, int32(len(e.b)-4))
+	if _, err := c.Write(e.b); err != nil {
+		return nil, err
+	}
+	if noResponse {
+		return nil, nil
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(c, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxResponseSize {
+		return nil, fmt.Errorf("kafka: response size %d exceeds %d", n, maxResponseSize)
+	}
+	resp := make([]byte, n)
+	if _, err := io.ReadFull(c, resp); err != nil {
+		return nil, err
+	}
+	d := &decoder{b: resp}
+	if cid := d.int32(); d.err != nil || cid != c.cid {
+		return nil, fmt.Errorf("kafka: unexpected correlation id %d, want %d", cid, c.cid)
+	}
+	return d.b, nil
+}
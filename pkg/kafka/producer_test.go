@@ -0,0 +1,218 @@
+package kafka_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/x-thooh/delay/pkg/kafka"
+	"github.com/x-thooh/delay/pkg/kafka/kafkatest"
+)
+
+func TestProducerSend(t *testing.T) {
+	b, err := kafkatest.NewBroker(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	p, err := kafka.NewProducer(&kafka.Config{Brokers: []string{b.Addr()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ts := time.UnixMilli(1700000000000)
+	for i := 0; i < 2; i++ {
+		pi, offset, err := p.Send(ctx, &kafka.Message{
+			Topic:   "orders",
+			Key:     []byte("A001"),
+			Value:   []byte(`{"order_id":"A001"}`),
+			Headers: []kafka.Header{{Key: "x-trace-id", Value: []byte("t-1")}},
+			Time:    ts,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if offset != int64(i) {
+			t.Fatalf("unexpected offset %d", offset)
+		}
+		records := b.Records("orders", int(pi))
+		if len(records) != i+1 {
+			t.Fatalf("unexpected records %d", len(records))
+		}
+		r := records[i]
+		if string(r.Key) != "A001" || string(r.Value) != `{"order_id":"A001"}` || !r.Time.Equal(ts) {
+			t.Fatalf("unexpected record %+v", r)
+		}
+		if len(r.Headers) != 1 || r.Headers[0].Key != "x-trace-id" || string(r.Headers[0].Value) != "t-1" {
+			t.Fatalf("unexpected headers %+v", r.Headers)
+		}
+	}
+}
+
+func TestProducerRetry(t *testing.T) {
+	b, err := kafkatest.NewBroker(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	p, _ := kafka.NewProducer(&kafka.Config{Brokers: []string{b.Addr()}})
+	defer p.Close()
+
+	// NOT_LEADER_FOR_PARTITION 刷新元数据后重试
+	b.FailProduce(6)
+	if _, _, err = p.Send(context.Background(), &kafka.Message{Topic: "orders", Value: []byte("1")}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(b.Records("orders", 0)); n != 1 {
+		t.Fatalf("unexpected records %d", n)
+	}
+
+	// 不可重试的错误直接返回
+	b.FailProduce(10)
+	_, _, err = p.Send(context.Background(), &kafka.Message{Topic: "orders", Value: []byte("2")})
+	if code, ok := err.(kafka.Error); !ok || code != 10 {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestProducerLeaderless(t *testing.T) {
+	b, err := kafkatest.NewBroker(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	p, _ := kafka.NewProducer(&kafka.Config{Brokers: []string{b.Addr()}, Retries: 2, Backoff: time.Millisecond})
+	defer p.Close()
+
+	// 有键消息的分区无 leader 时重试后明确失败
+	key := []byte("A001")
+	b.Leaderless(0, 1)
+	_, _, err = p.Send(context.Background(), &kafka.Message{Topic: "orders", Key: key, Value: []byte("2")})
+	var code kafka.Error
+	if !errors.As(err, &code) || code != 5 || !strings.Contains(err.Error(), "has no leader") {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// 等待重试期间 ctx 结束时返回
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err = p.Send(ctx, &kafka.Message{Topic: "orders", Key: key}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// 无键消息只发往有 leader 的分区
+	b.Leaderless(0)
+	for i := 0; i < 5; i++ {
+		pi, _, err := p.Send(context.Background(), &kafka.Message{Topic: "orders", Value: []byte("1")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pi != 1 {
+			t.Fatalf("unexpected partition %d", pi)
+		}
+	}
+
+	// 恢复后发送成功
+	b.Leaderless()
+	if _, _, err = p.Send(context.Background(), &kafka.Message{Topic: "orders", Key: key, Value: []byte("3")}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProducerNoBroker(t *testing.T) {
+	if _, err := kafka.NewProducer(&kafka.Config{}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// serveRaw 启动只响应一次的 broker，回写 resp（含长度前缀）
+func serveRaw(t *testing.T, resp []byte) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		var size [4]byte
+		if _, err = io.ReadFull(c, size[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err = io.ReadFull(c, req); err != nil {
+			return
+		}
+		// 沿用请求的 correlation id
+		if len(resp) >= 8 {
+			copy(resp[4:8], req[4:8])
+		}
+		_, _ = c.Write(resp)
+	}()
+	return ln.Addr().String()
+}
+
+func TestProducerMalformed(t *testing.T) {
+	be := binary.BigEndian
+	// 元数据响应：分区编号为 -1
+	b := be.AppendUint32(nil, 0) // 长度，最后填写
+	b = be.AppendUint32(b, 0)    // correlation id
+	b = be.AppendUint32(b, 0)    // throttle
+	b = be.AppendUint32(b, 1)    // brokers
+	b = be.AppendUint32(b, 0)
+	b = be.AppendUint16(b, 9)
+	b = append(b, "127.0.0.1"...)
+	b = be.AppendUint32(b, 9092)
+	b = be.AppendUint16(b, 0xffff) // rack
+	b = be.AppendUint16(b, 0xffff) // cluster id
+	b = be.AppendUint32(b, 0)      // controller
+	b = be.AppendUint32(b, 1)      // topics
+	b = be.AppendUint16(b, 0)
+	b = be.AppendUint16(b, 6)
+	b = append(b, "orders"...)
+	b = append(b, 0)
+	b = be.AppendUint32(b, 1) // partitions
+	b = be.AppendUint16(b, 0)
+	b = be.AppendUint32(b, 0xffffffff) // -1
+	b = be.AppendUint32(b, 0)
+	for i := 0; i < 3; i++ {
+		b = be.AppendUint32(b, 0)
+	}
+	be.PutUint32(b, uint32(len(b)-4))
+
+	for _, c := range []struct {
+		name string
+		resp []byte
+		want string
+	}{
+		{"negative partition", b, "invalid partition -1"},
+		{"oversized response", []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}, "exceeds"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			p, err := kafka.NewProducer(&kafka.Config{Brokers: []string{serveRaw(t, c.resp)}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if _, _, err = p.Send(ctx, &kafka.Message{Topic: "orders", Value: []byte("v")}); err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Fatalf("expected %q, got %v", c.want, err)
+			}
+		})
+	}
+}
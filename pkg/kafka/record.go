@@ -0,0 +1,188 @@
+package kafka
+
+import (
+	"hash/crc32"
+	"time"
+)
+
+// Header 消息头
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Message 待发送的消息
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers []Header
+	// 为空时使用发送时间
+	Time time.Time
+}
+
+// Record 解码后的消息，供测试及 kafkatest 使用
+type Record struct {
+	Key     []byte
+	Value   []byte
+	Headers []Header
+	Time    time.Time
+}
+
+// encodeBatch 编码为 v2 RecordBatch（magic 2），不压缩、非幂等
+func encodeBatch(msgs []*Message) []byte {
+	now := time.Now()
+	ts := func(m *Message) int64 {
+		if m.Time.IsZero() {
+			return now.UnixMilli()
+		}
+		return m.Time.UnixMilli()
+	}
+	first, last := ts(msgs[0]), ts(msgs[0])
+	for _, m := range msgs[1:] {
+		t := ts(m)
+		if t < first {
+			first = t
+		}
+		if t > last {
+			last = t
+		}
+	}
+
+	e := &encoder{}
+	e.int64(0) // baseOffset
+	e.int32(0) // batchLength，回填
+	e.int32(-1)
+	e.int8(2)
+	crcOff := len(e.b)
+	e.int32(0) // crc，回填
+	e.int16(0) // attributes
+	e.int32(int32(len(msgs) - 1))
+	e.int64(first)
+	e.int64(last)
+	e.int64(-1) // producerId
+	e.int16(-1) // producerEpoch
+	e.int32(-1) // baseSequence
+	e.int32(int32(len(msgs)))
+	for i, m := range msgs {
+		r := &encoder{}
+		r.int8(0)
+		r.varint(ts(m) - first)
+		r.varint(int64(i))
+		r.varbytes(m.Key)
+		r.varbytes(m.Value)
+		r.varint(int64(len(m.Headers)))
+		for _, h := range m.Headers {
+			r.varbytes([]byte(h.Key))
+			r.varbytes(h.Value)
+		}
+		e.varint(int64(len(r.b)))
+		e.b = append(e.b, r.b...)
+	}
+	e.putInt32(8, int32(len(e.b)-12))
+	e.putInt32(crcOff, int32(crc32Checksum(e.b[crcOff+4:])))
+	return e.b
+}
+
+// DecodeBatch 解码 v2 RecordBatch
+func DecodeBatch(b []byte) ([]*Record, error) {
+	var records []*Record
+	for len(b) > 0 {
+		d := &decoder{b: b}
+		d.int64()
+		n := d.int32()
+		batch := d.take(int(n))
+		if d.err != nil {
+			return nil, d.err
+		}
+		b = d.b
+
+		d = &decoder{b: batch}
+		d.int32()
+		if magic := d.int8(); magic != 2 {
+			return nil, Error(errCorruptMessage)
+		}
+		crc := uint32(d.int32())
+		if d.err == nil && crc32Checksum(d.b) != crc {
+			return nil, Error(errCorruptMessage)
+		}
+		if attrs := d.int16(); attrs&0x7 != 0 {
+			// 不支持压缩的消息
+			return nil, Error(errUnsupportedCompression)
+		}
+		d.int32()
+		first := d.int64()
+		d.int64()
+		d.int64()
+		d.int16()
+		d.int32()
+		count := d.arrayLen()
+		for i := 0; i < count; i++ {
+			size := d.varint()
+			rd := &decoder{b: d.take(int(size))}
+			rd.int8()
+			delta := rd.varint()
+			rd.varint()
+			r := &Record{
+				Key:   rd.varbytes(),
+				Value: rd.varbytes(),
+				Time:  time.UnixMilli(first + delta),
+			}
+			hn := rd.varint()
+			for j := int64(0); j < hn && rd.err == nil; j++ {
+				r.Headers = append(r.Headers, Header{Key: string(rd.varbytes()), Value: rd.varbytes()})
+			}
+			if rd.err != nil {
+				return nil, rd.err
+			}
+			records = append(records, r)
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+	}
+	return records, nil
+}
+
+func crc32Checksum(b []byte) uint32 {
+	return crc32.Checksum(b, crc32c)
+}
+
+// partition 与 Java 客户端默认分区器一致：murmur2(key) 取正后对分区数取模
+func partition(key []byte, n int) int32 {
+	return int32(int(murmur2(key)&0x7fffffff) % n)
+}
+
+func murmur2(data []byte) int32 {
+	const (
+		seed uint32 = 0x9747b28c
+		m    uint32 = 0x5bd1e995
+		r           = 24
+	)
+	length := len(data)
+	h := seed ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := uint32(data[i]) | uint32(data[i+1])<<8 | uint32(data[i+2])<<16 | uint32(data[i+3])<<24
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+	tail := length &^ 3
+	switch length & 3 {
+	case 3:
+		h ^= uint32(data[tail+2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[tail+1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[tail])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
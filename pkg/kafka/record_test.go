@@ -0,0 +1,40 @@
+package kafka
+
+import "testing"
+
+// 与 Java 客户端 Utils.murmur2 的结果一致
+func TestMurmur2(t *testing.T) {
+	cases := map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	}
+	for in, want := range cases {
+		if got := murmur2([]byte(in)); got != want {
+			t.Fatalf("murmur2(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	records, err := DecodeBatch(encodeBatch([]*Message{
+		{Key: nil, Value: []byte("a")},
+		{Key: []byte("k"), Value: nil, Headers: []Header{{Key: "h", Value: []byte("v")}}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Key != nil || string(records[0].Value) != "a" ||
+		string(records[1].Key) != "k" || records[1].Value != nil || records[1].Headers[0].Key != "h" {
+		t.Fatalf("unexpected records %+v", records)
+	}
+
+	b := encodeBatch([]*Message{{Value: []byte("a")}})
+	b[len(b)-1] ^= 0xff
+	if _, err = DecodeBatch(b); err == nil {
+		t.Fatal("expected crc error")
+	}
+}
@@ -0,0 +1,186 @@
+// Package keyring 信封加密：每条数据使用随机数据密钥（AES-256-GCM）加密，数据密钥再由密钥环中的主密钥加密。
+//
+// 密钥环文件为 yaml：
+//
+//	primary: "2025-01"
+//	keys:
+//	  - id: "2025-01"
+//	    key: "<base64 编码的 32 字节密钥>"
+//	  - id: "2024-07"
+//	    key: "..."
+//
+// 新数据使用 primary 加密，其他密钥仅用于解密；轮换时添加新密钥并设为 primary，
+// 旧数据通过 Rewrap 用新密钥重新加密数据密钥后再移除旧密钥。
+// 加密时可传入附加数据（如记录 id），解密时须一致，防止密文被复制到其他记录。
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrUnknownKey = errors.New("keyring: unknown key id")
+
+type file struct {
+	Primary string `yaml:"primary"`
+	Keys    []struct {
+		Id  string `yaml:"id"`
+		Key string `yaml:"key"`
+	} `yaml:"keys"`
+}
+
+// Sealed 加密后的数据，Key 为主密钥加密后的数据密钥
+type Sealed struct {
+	KeyId string `json:"kid"`
+	Key   []byte `json:"key"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// Load 读取密钥环文件
+func Load(path string) (*Keyring, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err = yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("keyring: %w", err)
+	}
+	keys := make(map[string][]byte, len(f.Keys))
+	for _, k := range f.Keys {
+		if keys[k.Id], err = base64.StdEncoding.DecodeString(k.Key); err != nil {
+			return nil, fmt.Errorf("keyring: key %q: %w", k.Id, err)
+		}
+	}
+	return New(f.Primary, keys)
+}
+
+// New 创建密钥环，密钥须为 32 字节
+func New(primary string, keys map[string][]byte) (*Keyring, error) {
+	k := &Keyring{primary: primary, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" {
+			return nil, errors.New("keyring: key id is required")
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("keyring: key %q must be 32 bytes", id)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+	}
+	if _, ok := k.keys[primary]; !ok {
+		return nil, fmt.Errorf("%w: primary %q", ErrUnknownKey, primary)
+	}
+	return k, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Primary 返回加密新数据使用的密钥 id
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// Seal 使用随机数据密钥加密 plaintext，aad 为绑定的附加数据
+func (k *Keyring) Seal(plaintext, aad []byte) (*Sealed, error) {
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	s := &Sealed{Nonce: make([]byte, aead.NonceSize())}
+	if _, err = rand.Read(s.Nonce); err != nil {
+		return nil, err
+	}
+	if err = k.wrap(s, dek); err != nil {
+		return nil, err
+	}
+	s.Data = aead.Seal(nil, s.Nonce, plaintext, aad)
+	return s, nil
+}
+
+// Open 解密，aad 须与加密时一致
+func (k *Keyring) Open(s *Sealed, aad []byte) ([]byte, error) {
+	dek, err := k.unwrap(s)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	b, err := aead.Open(nil, s.Nonce, s.Data, aad)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: open data: %w", err)
+	}
+	return b, nil
+}
+
+// Rewrap 用主密钥重新加密数据密钥，数据本身不变；已使用主密钥时返回 false
+func (k *Keyring) Rewrap(s *Sealed) (*Sealed, bool, error) {
+	if s.KeyId == k.primary {
+		return s, false, nil
+	}
+	dek, err := k.unwrap(s)
+	if err != nil {
+		return nil, false, err
+	}
+	r := &Sealed{Nonce: s.Nonce, Data: s.Data}
+	if err = k.wrap(r, dek); err != nil {
+		return nil, false, err
+	}
+	return r, true, nil
+}
+
+// wrap 用主密钥加密数据密钥，nonce 前置
+func (k *Keyring) wrap(s *Sealed, dek []byte) error {
+	aead := k.keys[k.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	s.KeyId = k.primary
+	s.Key = aead.Seal(nonce, nonce, dek, []byte(k.primary))
+	return nil
+}
+
+func (k *Keyring) unwrap(s *Sealed) ([]byte, error) {
+	aead, ok := k.keys[s.KeyId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, s.KeyId)
+	}
+	n := aead.NonceSize()
+	if len(s.Key) < n {
+		return nil, errors.New("keyring: malformed data key")
+	}
+	dek, err := aead.Open(nil, s.Key[:n], s.Key[n:], []byte(s.KeyId))
+	if err != nil {
+		return nil, fmt.Errorf("keyring: open data key: %w", err)
+	}
+	return dek, nil
+}
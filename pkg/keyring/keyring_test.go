@@ -0,0 +1,81 @@
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSealRewrap(t *testing.T) {
+	old, err := New("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	aad := []byte("42")
+	s, err := old.Seal([]byte("13800000000"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.KeyId != "k1" || bytes.Contains(s.Data, []byte("13800000000")) {
+		t.Fatalf("unexpected sealed %+v", s)
+	}
+
+	kr, err := New("k2", map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+		"k2": bytes.Repeat([]byte{2}, 32),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok, err := kr.Rewrap(s)
+	if err != nil || !ok || r.KeyId != "k2" {
+		t.Fatalf("unexpected rewrap %+v %v %v", r, ok, err)
+	}
+	if _, ok, _ = kr.Rewrap(r); ok {
+		t.Fatal("expected no rewrap for primary key")
+	}
+	b, err := kr.Open(r, aad)
+	if err != nil || string(b) != "13800000000" {
+		t.Fatalf("unexpected open %q %v", b, err)
+	}
+	// 附加数据不一致（密文被复制到其他记录）
+	if _, err = kr.Open(r, []byte("43")); err == nil {
+		t.Fatal("expected aad mismatch error")
+	}
+	if _, err = old.Open(r, aad); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected unknown key, got %v", err)
+	}
+
+	r.Data[0] ^= 1
+	if _, err = kr.Open(r, aad); err == nil {
+		t.Fatal("expected tampered data error")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.yaml")
+	if err := os.WriteFile(path, []byte(`
+primary: "2025-01"
+keys:
+  - id: "2025-01"
+    key: "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	kr, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr.Primary() != "2025-01" {
+		t.Fatalf("unexpected primary %q", kr.Primary())
+	}
+
+	if _, err = New("k2", map[string][]byte{"k1": make([]byte, 32)}); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected unknown primary, got %v", err)
+	}
+	if _, err = New("k1", map[string][]byte{"k1": make([]byte, 16)}); err == nil {
+		t.Fatal("expected key length error")
+	}
+}
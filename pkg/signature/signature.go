@@ -0,0 +1,77 @@
+// Package signature 回调请求签名，供回调接收方校验请求确实来自延迟服务。
+//
+// 签名为 hex(hmac_sha256(secret, timestamp + "." + body))，timestamp 为秒级时间戳，
+// 与任务编号一起通过请求头传递。
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderSignature = "X-Delay-Signature"
+	HeaderTimestamp = "X-Delay-Timestamp"
+	HeaderTaskNo    = "X-Delay-Task-No"
+
+	DefaultMaxSkew = 5 * time.Minute
+)
+
+var (
+	ErrMissing  = errors.New("signature: missing signature headers")
+	ErrExpired  = errors.New("signature: timestamp out of range")
+	ErrMismatch = errors.New("signature: mismatch")
+)
+
+// Sign 计算签名
+func Sign(secret string, timestamp int64, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	m.Write([]byte("."))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// Verify 校验签名与时间戳，maxSkew 为 0 时使用 DefaultMaxSkew
+func Verify(secret, timestamp, signature string, body []byte, maxSkew time.Duration) error {
+	if timestamp == "" || signature == "" {
+		return ErrMissing
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrExpired
+	}
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxSkew
+	}
+	if d := time.Since(time.Unix(ts, 0)); d > maxSkew || d < -maxSkew {
+		return ErrExpired
+	}
+	if !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// VerifyRequest 校验 HTTP 回调请求，返回请求体，请求体可被再次读取
+func VerifyRequest(r *http.Request, secret string, maxSkew time.Duration) ([]byte, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if err := Verify(secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, maxSkew); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,42 @@
+package signature
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	body := []byte(`{"result":"SUCCESS"}`)
+	ts := time.Now().Unix()
+
+	r := httptest.NewRequest("POST", "/callback", bytes.NewReader(body))
+	r.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	r.Header.Set(HeaderSignature, Sign("secret", ts, body))
+	got, err := VerifyRequest(r, "secret", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Fatalf("unexpected body %s", got)
+	}
+	if again, _ := io.ReadAll(r.Body); !bytes.Equal(again, body) {
+		t.Fatalf("body should be readable again, got %s", again)
+	}
+
+	r = httptest.NewRequest("POST", "/callback", bytes.NewReader(body))
+	r.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	r.Header.Set(HeaderSignature, Sign("other", ts, body))
+	if _, err = VerifyRequest(r, "secret", 0); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("expected mismatch, got %v", err)
+	}
+
+	old := time.Now().Add(-time.Hour).Unix()
+	if err = Verify("secret", strconv.FormatInt(old, 10), Sign("secret", old, body), body, time.Minute); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected expired, got %v", err)
+	}
+}
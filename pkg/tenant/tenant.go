@@ -0,0 +1,29 @@
+package tenant
+
+import (
+	"context"
+)
+
+const (
+	tenantCtxKey    = "tenant"
+	tenantHeaderKey = "X-Tenant-Id"
+)
+
+func GetCtxKey() string {
+	return tenantCtxKey
+}
+
+func GetHeaderKey() string {
+	return tenantHeaderKey
+}
+
+func Set(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, GetCtxKey(), tenant)
+}
+
+func Get(ctx context.Context) string {
+	if t, ok := ctx.Value(GetCtxKey()).(string); ok {
+		return t
+	}
+	return ""
+}
@@ -0,0 +1,20 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTenant(t *testing.T) {
+	ctx := context.Background()
+	if tn := Get(ctx); tn != "" {
+		t.Fatalf("expected empty tenant, got %q", tn)
+	}
+	ctx = Set(ctx, "order")
+	if tn := Get(ctx); tn != "order" {
+		t.Fatalf("expected order, got %q", tn)
+	}
+	if tn := Get(Set(ctx, "pay")); tn != "pay" {
+		t.Fatalf("expected pay, got %q", tn)
+	}
+}
@@ -0,0 +1,94 @@
+package tlsx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+type Config struct {
+	// 是否启用 TLS；HTTP 回调按地址协议决定，忽略该项
+	Enable bool `yaml:"enable"`
+	// CA 证书，服务端用于校验客户端证书，客户端用于校验服务端证书
+	CAFile string `yaml:"ca_file"`
+	// 证书与私钥，服务端证书或客户端证书（mTLS）
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// 客户端校验的服务端名称
+	ServerName string `yaml:"server_name"`
+	// 客户端跳过证书校验
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+	// 服务端要求并校验客户端证书，需配置 ca_file
+	ClientAuth bool `yaml:"client_auth"`
+}
+
+// Enabled 是否启用 TLS
+func (c *Config) Enabled() bool {
+	return c != nil && c.Enable
+}
+
+// ServerConfig 构造服务端 TLS 配置
+func (c *Config) ServerConfig() (*tls.Config, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, fmt.Errorf("tls: cert_file and key_file are required")
+	}
+	if c.ClientAuth && c.CAFile == "" {
+		// 未配置 CA 时会使用系统根证书校验客户端，任意公共 CA 签发的证书都能通过
+		return nil, fmt.Errorf("tls: ca_file is required when client_auth is enabled")
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: load key pair: %w", err)
+	}
+	tc := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.CAFile != "" {
+		if tc.ClientCAs, err = loadPool(c.CAFile); err != nil {
+			return nil, err
+		}
+	}
+	if c.ClientAuth {
+		tc.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tc, nil
+}
+
+// ClientConfig 构造客户端 TLS 配置，nil 时返回默认配置
+func (c *Config) ClientConfig() (*tls.Config, error) {
+	tc := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c == nil {
+		return tc, nil
+	}
+	tc.ServerName = c.ServerName
+	tc.InsecureSkipVerify = c.InsecureSkipVerify
+	if c.CAFile != "" {
+		pool, err := loadPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tc.RootCAs = pool
+	}
+	if c.CertFile != "" && c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: load key pair: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	return tc, nil
+}
+
+func loadPool(file string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("tls: read ca %s: %w", file, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("tls: no certificate found in %s", file)
+	}
+	return pool, nil
+}
@@ -0,0 +1,163 @@
+package tlsx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type pair struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	Cert string
+	Key  string
+}
+
+// issue 生成证书，parent 为空时自签名
+func issue(t *testing.T, dir, name string, parent *pair, isCA bool) *pair {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	parentCert, parentKey := tpl, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &pair{cert: cert, key: key, Cert: filepath.Join(dir, name+".crt"), Key: filepath.Join(dir, name+".key")}
+	if err = os.WriteFile(p.Cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(p.Key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// handshake 通过本地连接完成 TLS 握手
+func handshake(t *testing.T, server, client *tls.Config) (error, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	ch := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			ch <- err
+			return
+		}
+		defer conn.Close()
+		ch <- tls.Server(conn, server).Handshake()
+	}()
+	conn, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// TLS 1.3 中服务端在客户端握手完成后才校验客户端证书，以服务端结果为准
+	cErr := tls.Client(conn, client).Handshake()
+	return <-ch, cErr
+}
+
+func TestServerConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, dir, "ca", nil, true)
+	srv := issue(t, dir, "server.local", ca, false)
+
+	if _, err := (&Config{Enable: true}).ServerConfig(); err == nil {
+		t.Fatal("expected error without cert")
+	}
+	if _, err := (&Config{Enable: true, CertFile: srv.Cert, KeyFile: srv.Key, ClientAuth: true}).ServerConfig(); err == nil {
+		t.Fatal("expected error when client_auth without ca_file")
+	}
+	if _, err := (&Config{Enable: true, CertFile: srv.Cert, KeyFile: srv.Key, CAFile: filepath.Join(dir, "missing")}).ServerConfig(); err == nil {
+		t.Fatal("expected error with missing ca")
+	}
+	tc, err := (&Config{Enable: true, CertFile: srv.Cert, KeyFile: srv.Key, CAFile: ca.Cert, ClientAuth: true}).ServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.ClientAuth != tls.RequireAndVerifyClientCert || tc.ClientCAs == nil {
+		t.Fatalf("unexpected client auth %v", tc.ClientAuth)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, dir, "ca", nil, true)
+	other := issue(t, dir, "other-ca", nil, true)
+	srv := issue(t, dir, "server.local", ca, false)
+	cli := issue(t, dir, "client", ca, false)
+	stranger := issue(t, dir, "stranger", other, false)
+
+	server, err := (&Config{Enable: true, CertFile: srv.Cert, KeyFile: srv.Key, CAFile: ca.Cert, ClientAuth: true}).ServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := (&Config{Enable: true, CAFile: ca.Cert, CertFile: cli.Cert, KeyFile: cli.Key, ServerName: "server.local"}).ClientConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sErr, cErr := handshake(t, server, client); sErr != nil || cErr != nil {
+		t.Fatalf("handshake: server %v, client %v", sErr, cErr)
+	}
+
+	// 其他 CA 签发的客户端证书
+	client, err = (&Config{Enable: true, CAFile: ca.Cert, CertFile: stranger.Cert, KeyFile: stranger.Key, ServerName: "server.local"}).ClientConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sErr, _ := handshake(t, server, client); sErr == nil {
+		t.Fatal("expected server to reject untrusted client certificate")
+	}
+
+	// 客户端不信任服务端证书
+	client, err = (&Config{Enable: true, CAFile: other.Cert, ServerName: "server.local"}).ClientConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, cErr := handshake(t, server, client); cErr == nil {
+		t.Fatal("expected client to reject untrusted server certificate")
+	}
+}
+
+func TestClientConfig(t *testing.T) {
+	tc, err := (*Config)(nil).ClientConfig()
+	if err != nil || tc.MinVersion != tls.VersionTLS12 || tc.RootCAs != nil {
+		t.Fatalf("unexpected default config %v %+v", err, tc)
+	}
+	if (*Config)(nil).Enabled() || (&Config{}).Enabled() {
+		t.Fatal("expected disabled")
+	}
+}
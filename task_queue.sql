@@ -1,23 +1,73 @@
 CREATE TABLE task_queue (
     id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
     task_no BIGINT UNSIGNED NOT NULL COMMENT '任务唯一编号，雪花算法生成，业务唯一标识',
+    tenant VARCHAR(64) NOT NULL DEFAULT '' COMMENT '租户',
     payload JSON NULL COMMENT '任务数据-{"callback":"xxx", "data":{}}',
     delay_time INT NOT NULL DEFAULT 0 COMMENT '延迟秒数，>0表示延迟任务',
     timeout INT NOT NULL DEFAULT 60 COMMENT '任务超时时间(秒)',
     backoff JSON NULL COMMENT '失败重试间隔数组,单位秒，例如 [5,15,60]',
     cron_expr VARCHAR(100) NULL COMMENT 'Cron 表达式，NULL表示一次性任务',
-    `status` TINYINT NOT NULL DEFAULT 0 COMMENT '0待执行 1执行中 2成功 3失败',
+    `status` TINYINT NOT NULL DEFAULT 0 COMMENT '0待执行 1执行中 2成功 3失败 4已取消 5等待父任务',
     next_run_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) COMMENT '下次执行时间',
     run_timeout_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) COMMENT '执行超时时间:下次执行时间+超时时间',
     fail_count INT NOT NULL DEFAULT 0 COMMENT '当前失败次数',
     fail_msgs JSON NULL COMMENT '失败信息数组',
+    result TEXT NULL COMMENT '成功回调响应（截断）',
     last_retry_at DATETIME(6) NULL COMMENT '最后一次重试时间',
-    locked_by TINYINT NOT NULL DEFAULT 0 COMMENT '锁定程序序号',
+    locked_by TINYINT NOT NULL DEFAULT 0 COMMENT '执行节点编号',
+    slot SMALLINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '槽位：CRC32(task_no) % 1024，按一致性哈希分配给节点',
     extra JSON NULL COMMENT '额外信息',
     created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
     updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
     PRIMARY KEY (id),
     UNIQUE KEY udx_task_no(task_no),
-    KEY idx_status_next_run_locked_by (`status`, next_run_at, locked_by),
-    KEY idx_status_timeout_at (`status`, run_timeout_at, locked_by)
+    KEY idx_status_next_run_slot (`status`, next_run_at, slot),
+    KEY idx_status_timeout_at (`status`, run_timeout_at, slot),
+    KEY idx_status_slot_locked_by (`status`, slot, locked_by),
+    KEY idx_tenant_status (tenant, `status`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE task_dependency (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    child_task_no BIGINT UNSIGNED NOT NULL COMMENT '子任务编号',
+    parent_task_no BIGINT UNSIGNED NOT NULL COMMENT '父任务编号',
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (id),
+    UNIQUE KEY udx_child_parent(child_task_no, parent_task_no),
+    KEY idx_parent(parent_task_no)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE node_registry (
+    node_id INT NOT NULL COMMENT '节点编号',
+    instance VARCHAR(128) NOT NULL COMMENT '实例标识：主机名:进程号:随机串',
+    heartbeat_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) COMMENT '最后心跳时间',
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) COMMENT '注册时间',
+    PRIMARY KEY (node_id),
+    KEY idx_heartbeat_at (heartbeat_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE leader_lease (
+    name VARCHAR(64) NOT NULL COMMENT '租约名称',
+    holder VARCHAR(128) NOT NULL COMMENT '持有者实例标识',
+    expires_at DATETIME(6) NOT NULL COMMENT '到期时间',
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE tenant_quota (
+    tenant VARCHAR(64) NOT NULL COMMENT '租户',
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) COMMENT '最后注册时间',
+    PRIMARY KEY (tenant)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 升级：租户
+-- ALTER TABLE task_queue ADD COLUMN tenant VARCHAR(64) NOT NULL DEFAULT '' COMMENT '租户' AFTER task_no, ADD KEY idx_tenant_status (tenant, `status`);
+-- 升级：成功回调响应
+-- ALTER TABLE task_queue ADD COLUMN result TEXT NULL COMMENT '成功回调响应（截断）' AFTER fail_msgs;
+-- 升级：任务依赖，新建 task_dependency 表
+-- 升级：节点注册，新建 node_registry 表
+-- 升级：任务槽位
+-- ALTER TABLE task_queue ADD COLUMN slot SMALLINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '槽位：CRC32(task_no) % 1024，按一致性哈希分配给节点' AFTER locked_by, DROP KEY idx_status_next_run_locked_by, ADD KEY idx_status_next_run_slot (`status`, next_run_at, slot), DROP KEY idx_status_timeout_at, ADD KEY idx_status_timeout_at (`status`, run_timeout_at, slot), ADD KEY idx_status_slot_locked_by (`status`, slot, locked_by);
+-- UPDATE task_queue SET slot = CRC32(task_no) % 1024;
+-- 升级：选主，新建 leader_lease 表
+-- 升级：租户配额，新建 tenant_quota 表